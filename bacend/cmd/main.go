@@ -3,11 +3,18 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
 
 	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/jobs"
 	"Gin-Blog-Website/models" // IMPORTANT: Import your models package
 	"Gin-Blog-Website/platform/cloudinary"
+	"Gin-Blog-Website/platform/mailer"
+	"Gin-Blog-Website/platform/oidc"
+	"Gin-Blog-Website/platform/throttle"
 	"Gin-Blog-Website/routes"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -21,17 +28,79 @@ func main() {
 		log.Fatal("Error loading .env file. Please create one.")
 	}
 
+	// Load the JWT signing keys; refuse to start with no or weak keys
+	if err := utils.InitSigningKeys(); err != nil {
+		log.Fatalf("Invalid JWT key configuration: %v", err)
+	}
+
 	// Connect to the database
 	database.Connect()
 
 	// NEW: Initialize Cloudinary
 	cloudinary.InitCloudinary()
 
+	// Configure the outgoing mail transport (SMTP, file or log)
+	mailer.InitMailer()
+
+	// Load the OpenID Connect providers used for social login
+	oidc.InitProviders()
+
+	// Select the counter store used for login throttling
+	throttle.InitStore()
+
+	// Accounts from before email verification count as verified; this has to
+	// see the users table as it was before AutoMigrate
+	if err := services.MigrateEmailVerification(); err != nil {
+		log.Fatalf("Failed to migrate email verification: %v", err)
+	}
+
 	// AutoMigrate all your models to ensure database tables are up-to-date
-	// This is crucial for adding the new 'is_approved' columns to 'blogs' and 'comments' tables.
-	database.DB.AutoMigrate(&models.User{}, &models.Blog{}, &models.Comment{})
+	err = database.DB.AutoMigrate(
+		&models.User{},
+		&models.Blog{},
+		&models.Comment{},
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.PasswordResetToken{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
+		&models.APIToken{},
+		&models.Permission{},
+		&models.Role{},
+		&models.AccountDeletion{},
+		&models.PostStatusChange{},
+		&models.PostRevision{},
+		&models.PostSlug{},
+		&models.Tag{},
+		&models.Category{},
+		&models.PostLike{},
+	)
+	if err != nil {
+		log.Fatalf("Error during AutoMigrate: %v", err)
+	}
+	if err := services.MigrateLegacyPostApproval(); err != nil {
+		log.Fatalf("Failed to migrate post approval flags to statuses: %v", err)
+	}
+	if err := services.BackfillPublishedAt(); err != nil {
+		log.Fatalf("Failed to backfill post publication times: %v", err)
+	}
+	if err := services.BackfillPostSlugs(); err != nil {
+		log.Fatalf("Failed to generate post slugs: %v", err)
+	}
+	if err := services.MigratePostSearch(); err != nil {
+		log.Fatalf("Failed to set up post search: %v", err)
+	}
 	log.Println("Database migrations completed.")
 
+	// Make sure the default roles and permissions exist
+	if err := services.SeedRoles(); err != nil {
+		log.Fatalf("Failed to seed roles and permissions: %v", err)
+	}
+
+	// Start background maintenance such as scheduled account deletions
+	jobs.Start()
+
 	// Get port from environment variable
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -41,12 +110,20 @@ func main() {
 	// Initialize Gin default router
 	app := gin.Default()
 
+	// Only trust X-Forwarded-For from the reverse proxies in TRUSTED_PROXIES, a
+	// comma-separated list of IPs or CIDRs. By default no proxy is trusted and
+	// the client IP used for login throttling and sessions is the connecting
+	// address, since otherwise any client could pick its own IP.
+	if err := app.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Configure CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"}, // Allow your frontend origin
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           86400, // Cache preflight requests for 24 hours
 	}))
@@ -61,3 +138,14 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// trustedProxies reads the TRUSTED_PROXIES list; empty means no proxy is trusted.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/platform/mailer"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// emailChangeTTL is how long a confirmation link for a new email address stays valid.
+const emailChangeTTL = 24 * time.Hour
+
+var (
+	errEmailChangeInvalid = errors.New("email change token is invalid")
+	errEmailTaken         = errors.New("email address is already in use")
+)
+
+// reauthenticate checks the current password of the authenticated user before a
+// sensitive change. Wrong guesses count towards the login lockout, so a hijacked
+// session cannot be used to brute-force the password. It returns false when the
+// request was rejected.
+func reauthenticate(c *gin.Context, user models.User, password string) bool {
+	if wait := services.LoginLockedFor(user.Email, c.ClientIP()); wait > 0 {
+		respondLoginLocked(c, wait)
+		return false
+	}
+
+	if len(user.Password) == 0 {
+		c.JSON(400, gin.H{"message": "Your account has no password yet. Use \"Forgot password\" to set one."})
+		return false
+	}
+
+	if err := user.ComparePassword(password); err != nil {
+		if wait := services.RecordLoginFailure(user.Email, c.ClientIP()); wait > 0 {
+			respondLoginLocked(c, wait)
+			return false
+		}
+		c.JSON(401, gin.H{"message": "Current password is incorrect."})
+		return false
+	}
+	return true
+}
+
+// ChangeMyPassword changes the authenticated user's password after confirming the
+// current one. Every other session is logged out; the current one stays signed in.
+func ChangeMyPassword(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Current and new password are required."})
+		return
+	}
+
+	if !validatePassword(input.NewPassword) {
+		c.JSON(400, gin.H{"message": "Password must be greater than 6 characters!"})
+		return
+	}
+
+	if !reauthenticate(c, user, input.CurrentPassword) {
+		return
+	}
+
+	if err := user.SetPassword(input.NewPassword); err != nil {
+		log.Printf("Error hashing new password for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to change password due to server error."})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", user.Password).Error; err != nil {
+			return err
+		}
+		return services.RevokeUserSessions(tx, user.Id, c.GetString("sessionID"))
+	})
+	if err != nil {
+		log.Printf("Error changing password for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to change password due to server error."})
+		return
+	}
+
+	go func(user models.User) {
+		err := mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: "Your Gin Blog password was changed",
+			Body: fmt.Sprintf("Hi %s,\n\nThe password for your account was just changed and your other devices were logged out.\n\n"+
+				"If you did not do this, reset your password immediately:\n%s",
+				user.FirstName, frontendURL("/forgot-password")),
+		})
+		if err != nil {
+			log.Printf("Error sending password change notice to user %d: %v\n", user.Id, err)
+		}
+	}(user)
+
+	c.JSON(200, gin.H{"message": "Password changed successfully! Your other sessions have been logged out."})
+}
+
+// RequestEmailChange starts changing the authenticated user's email address after
+// confirming their password. A confirmation link goes to the new address and a
+// notice to the current one; the address only changes once the link is used.
+func RequestEmailChange(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		NewEmail        string `json:"new_email" binding:"required"`
+		CurrentPassword string `json:"current_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "New email and current password are required."})
+		return
+	}
+
+	newEmail := strings.TrimSpace(input.NewEmail)
+	if !validateEmail(newEmail) {
+		c.JSON(400, gin.H{"message": "Invalid Email Address!"})
+		return
+	}
+	if newEmail == user.Email {
+		c.JSON(400, gin.H{"message": "That is already your email address."})
+		return
+	}
+
+	if !reauthenticate(c, user, input.CurrentPassword) {
+		return
+	}
+
+	var existing int64
+	if err := database.DB.Unscoped().Model(&models.User{}).Where("email = ?", newEmail).Count(&existing).Error; err != nil {
+		log.Printf("Database error checking email availability for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to start email change due to server error."})
+		return
+	}
+	if existing > 0 {
+		c.JSON(400, gin.H{"message": "Email already exists!"})
+		return
+	}
+
+	token, err := utils.GenerateEmailChangeToken(strconv.Itoa(int(user.Id)), user.Email, newEmail, emailChangeTTL)
+	if err != nil {
+		log.Printf("Error generating email change token for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to start email change due to server error."})
+		return
+	}
+
+	err = mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new Gin Blog email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm that you want to use this address for your Gin Blog account:\n%s\n\n"+
+			"This link expires in 24 hours.",
+			user.FirstName, frontendURL("/confirm-email-change?token="+token)),
+	})
+	if err != nil {
+		log.Printf("Error sending email change confirmation for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to send confirmation email."})
+		return
+	}
+
+	err = mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your Gin Blog email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nA request was made to change the email address of your account to %s. "+
+			"The change takes effect once it is confirmed from the new address.\n\n"+
+			"If you did not request this, change your password immediately:\n%s",
+			user.FirstName, newEmail, frontendURL("/forgot-password")),
+	})
+	if err != nil {
+		// The change itself can still proceed; the user knows they started it.
+		log.Printf("Error sending email change notice to user %d: %v\n", user.Id, err)
+	}
+
+	c.JSON(200, gin.H{"message": "Confirmation email sent! Please check the inbox of your new address."})
+}
+
+// ConfirmEmailChange applies an email change from the link sent to the new address.
+// The new address counts as verified since the user has just proven they own it.
+func ConfirmEmailChange(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Confirmation token is required."})
+		return
+	}
+
+	claims, err := utils.ParsePurposeToken(input.Token, utils.PurposeEmailChange)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "This confirmation link is invalid or has expired."})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, claims.Issuer).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errEmailChangeInvalid
+			}
+			return err
+		}
+		// Only valid while the account still has the address the change was requested from.
+		if user.Email != claims.Subject {
+			return errEmailChangeInvalid
+		}
+
+		var existing int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", claims.Email, user.Id).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errEmailTaken
+		}
+
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"email":             claims.Email,
+			"email_verified_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errEmailChangeInvalid) {
+			c.JSON(400, gin.H{"message": "This confirmation link is invalid or has expired."})
+			return
+		}
+		if errors.Is(err, errEmailTaken) {
+			c.JSON(400, gin.H{"message": "Email already exists!"})
+			return
+		}
+		log.Printf("Error confirming email change for user %s: %v\n", claims.Issuer, err)
+		c.JSON(500, gin.H{"message": "Failed to change email due to server error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Email address changed successfully!"})
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/platform/mailer"
+	"Gin-Blog-Website/services"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExportMyData sends the authenticated user a ZIP archive of their profile,
+// posts, comments and uploaded image URLs as JSON and Markdown.
+func ExportMyData(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	archive, err := services.BuildAccountExport(userID)
+	if err != nil {
+		log.Printf("Error building data export for user %d: %v\n", userID, err)
+		c.JSON(500, gin.H{"message": "Failed to export your data due to server error."})
+		return
+	}
+
+	filename := fmt.Sprintf("gin-blog-export-%d-%s.zip", userID, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(200, "application/zip", archive)
+}
+
+// GetMyAccountDeletion returns the authenticated user's pending deletion request, if any.
+func GetMyAccountDeletion(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var deletion models.AccountDeletion
+	if err := database.DB.Where("user_id = ?", userID).First(&deletion).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(200, gin.H{"deletion": nil})
+			return
+		}
+		log.Printf("Database error fetching account deletion for user %d: %v\n", userID, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve account deletion status."})
+		return
+	}
+
+	c.JSON(200, gin.H{"deletion": deletion})
+}
+
+// ScheduleMyAccountDeletion schedules the authenticated user's account for deletion
+// after the grace period, once they have confirmed their password. "content"
+// chooses whether their posts and comments are anonymized or deleted with it.
+func ScheduleMyAccountDeletion(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		Content         string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Current password and a content choice (anonymize or delete) are required."})
+		return
+	}
+
+	if !models.IsValidContentPolicy(input.Content) {
+		c.JSON(400, gin.H{"message": "Content must be either 'anonymize' or 'delete'."})
+		return
+	}
+
+	if !reauthenticate(c, user, input.CurrentPassword) {
+		return
+	}
+
+	deletion, err := services.ScheduleAccountDeletion(user.Id, input.Content)
+	if err != nil {
+		log.Printf("Database error scheduling account deletion for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to schedule account deletion due to server error."})
+		return
+	}
+
+	go func(user models.User, deletion models.AccountDeletion) {
+		err := mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: "Your Gin Blog account is scheduled for deletion",
+			Body: fmt.Sprintf("Hi %s,\n\nYour account will be permanently deleted on %s. "+
+				"Until then you can log in and cancel the deletion from your account settings:\n%s",
+				user.FirstName, deletion.ScheduledFor.Format("2 January 2006 15:04 MST"), frontendURL("/my-profile")),
+		})
+		if err != nil {
+			log.Printf("Error sending account deletion notice to user %d: %v\n", user.Id, err)
+		}
+	}(user, *deletion)
+
+	c.JSON(200, gin.H{
+		"message":  "Your account is scheduled for deletion. You can cancel until the date below.",
+		"deletion": deletion,
+	})
+}
+
+// CancelMyAccountDeletion withdraws the authenticated user's pending deletion request.
+func CancelMyAccountDeletion(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	cancelled, err := services.CancelAccountDeletion(userID)
+	if err != nil {
+		log.Printf("Database error cancelling account deletion for user %d: %v\n", userID, err)
+		c.JSON(500, gin.H{"message": "Failed to cancel account deletion due to server error."})
+		return
+	}
+	if !cancelled {
+		c.JSON(404, gin.H{"message": "Your account is not scheduled for deletion."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Account deletion cancelled."})
+}
@@ -3,8 +3,14 @@ package controller
 import (
 	"Gin-Blog-Website/database"
 	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	// Added for string manipulation if needed for error checks
 	// Added for time.Now() if not already there, for timestamps
@@ -12,17 +18,123 @@ import (
 	"gorm.io/gorm"
 )
 
+// --- Admin List Pagination ---
+
+// Page sizes of the admin list endpoints.
+const (
+	defaultAdminPageSize = 20
+	maxAdminPageSize     = 100
+)
+
+// likeEscaper escapes the LIKE wildcards in user supplied search text.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// adminSearch narrows query to rows where any of columns contains the q
+// query parameter, ignoring case.
+func adminSearch(c *gin.Context, query *gorm.DB, columns ...string) *gorm.DB {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return query
+	}
+	pattern := "%" + likeEscaper.Replace(q) + "%"
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = column + " ILIKE ?"
+		args[i] = pattern
+	}
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// adminFilters applies the author (user ID) and from/to date range query
+// parameters to query, responding with 400 itself when they are malformed.
+// authorColumn may be empty for lists without an author.
+func adminFilters(c *gin.Context, query *gorm.DB, authorColumn string, dateColumn string) (*gorm.DB, bool) {
+	if raw := c.Query("author"); raw != "" && authorColumn != "" {
+		authorID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"message": "author must be a user ID."})
+			return nil, false
+		}
+		query = query.Where(authorColumn+" = ?", authorID)
+	}
+
+	from, okFrom := parseListingDate(c.Query("from"), false)
+	to, okTo := parseListingDate(c.Query("to"), true)
+	if !okFrom || !okTo {
+		c.JSON(400, gin.H{"message": "from and to must be dates (YYYY-MM-DD) or RFC 3339 timestamps."})
+		return nil, false
+	}
+	if from != nil {
+		query = query.Where(dateColumn+" >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where(dateColumn+" < ?", *to)
+	}
+	return query, true
+}
+
+// paginateAdminList loads the page of query selected by the page and limit
+// query parameters into dest, with the named associations preloaded, and
+// returns the meta block GetAllPost uses. It responds with 400/500 itself and
+// returns false when the request failed.
+func paginateAdminList(c *gin.Context, query *gorm.DB, dest interface{}, what string, preloads ...string) (gin.H, bool) {
+	limit := defaultAdminPageSize
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxAdminPageSize {
+			c.JSON(400, gin.H{"message": fmt.Sprintf("limit must be between 1 and %d.", maxAdminPageSize)})
+			return nil, false
+		}
+		limit = parsed
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		log.Printf("Admin: Database error counting %s: %v\n", what, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve " + what + "."})
+		return nil, false
+	}
+	pageQuery := query.Offset((page - 1) * limit).Limit(limit)
+	for _, association := range preloads {
+		pageQuery = pageQuery.Preload(association)
+	}
+	if err := pageQuery.Find(dest).Error; err != nil {
+		log.Printf("Admin: Database error retrieving %s: %v\n", what, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve " + what + "."})
+		return nil, false
+	}
+
+	return gin.H{
+		"total":     total,
+		"page":      page,
+		"last_page": int(math.Ceil(float64(total) / float64(limit))),
+		"limit":     limit,
+	}, true
+}
+
 // --- Admin User Management ---
 
-// GetAllUsersForAdmin retrieves all users in the system.
-// Requires AdminMiddleware.
+// GetAllUsersForAdmin retrieves the users in the system a page at a time,
+// newest first. q searches email and name; role, from and to filter by role
+// and registration date.
+// Requires the users.manage permission.
 func GetAllUsersForAdmin(c *gin.Context) {
 	var users []models.User
-	result := database.DB.Find(&users) // Fetch all users
-
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		log.Printf("Admin: Database error retrieving all users: %v\n", result.Error)
-		c.JSON(500, gin.H{"message": "Failed to retrieve users."})
+	query := adminSearch(c, database.DB.Model(&models.User{}), "email", "first_name", "last_name", "first_name || ' ' || last_name")
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	query, ok := adminFilters(c, query, "", "created_at")
+	if !ok {
+		return
+	}
+	meta, ok := paginateAdminList(c, query.Order("created_at desc, id desc"), &users, "users")
+	if !ok {
 		return
 	}
 
@@ -31,18 +143,26 @@ func GetAllUsersForAdmin(c *gin.Context) {
 		users[i].Password = nil // Clear the password hash before sending
 	}
 
-	c.JSON(200, gin.H{"data": users})
+	c.JSON(200, gin.H{"data": users, "meta": meta})
 }
 
-// UpdateUserRoleAsAdmin allows an admin to update another user's role.
-// Requires AdminMiddleware.
+// UpdateUserRoleAsAdmin allows an admin to update another user's role. Admins
+// cannot change their own role, and can only move users out of or into roles
+// whose permissions they hold themselves, so users.manage cannot be used to
+// gain more permissions.
+// Requires the users.manage permission.
 func UpdateUserRoleAsAdmin(c *gin.Context) {
+	caller := c.MustGet("user").(models.User)
 	targetUserIDStr := c.Param("id")
 	targetUserID, err := strconv.ParseUint(targetUserIDStr, 10, 32)
 	if err != nil {
 		c.JSON(400, gin.H{"message": "Invalid user ID format."})
 		return
 	}
+	if uint(targetUserID) == caller.Id {
+		c.JSON(403, gin.H{"message": "Admins cannot change their own role."})
+		return
+	}
 
 	var data struct {
 		Role string `json:"role" binding:"required"`
@@ -52,9 +172,15 @@ func UpdateUserRoleAsAdmin(c *gin.Context) {
 		return
 	}
 
-	// Validate the role
-	if data.Role != "user" && data.Role != "admin" {
-		c.JSON(400, gin.H{"message": "Invalid role. Role must be 'user' or 'admin'."})
+	// Validate the role against the roles table
+	var role models.Role
+	if err := database.DB.Where("name = ?", data.Role).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(400, gin.H{"message": "Invalid role. Role '" + data.Role + "' does not exist."})
+			return
+		}
+		log.Printf("Admin: Database error finding role '%s': %v\n", data.Role, err)
+		c.JSON(500, gin.H{"message": "Failed to update user role."})
 		return
 	}
 
@@ -69,9 +195,23 @@ func UpdateUserRoleAsAdmin(c *gin.Context) {
 		return
 	}
 
-	// Prevent admin from changing their own role (optional, but good practice)
-	// You can get current admin's ID from c.Get("userID") if needed.
-	// For simplicity, we're not adding that check here, but it's a consideration.
+	// Both the role being granted and the one being taken away must be within
+	// the caller's own permissions
+	for _, roleName := range []string{role.Name, user.Role} {
+		missing, err := services.MissingPermissions(caller, roleName)
+		if err != nil {
+			log.Printf("Admin: Database error comparing permissions of user %d and role '%s': %v\n", caller.Id, roleName, err)
+			c.JSON(500, gin.H{"message": "Failed to update user role."})
+			return
+		}
+		if len(missing) > 0 {
+			c.JSON(403, gin.H{
+				"message": "You cannot assign or revoke the role '" + roleName + "' because it grants permissions you do not have.",
+				"missing": missing,
+			})
+			return
+		}
+	}
 
 	user.Role = data.Role
 	if err := database.DB.Save(&user).Error; err != nil {
@@ -84,9 +224,26 @@ func UpdateUserRoleAsAdmin(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "User role updated successfully!", "user": user})
 }
 
-// DeleteUserAsAdmin allows an admin to delete any user by their ID.
-// Requires AdminMiddleware.
+// errDryRun rolls back the transaction of a dry-run deletion.
+var errDryRun = errors.New("dry run")
+
+// adminDeletionPolicies maps the policies an admin can pick when deleting a
+// user to what happens to that user's posts and comments.
+var adminDeletionPolicies = map[string]string{
+	"cascade":   models.ContentPolicyDelete,
+	"reassign":  models.ContentPolicyReassign,
+	"anonymize": models.ContentPolicyAnonymize,
+}
+
+// DeleteUserAsAdmin allows an admin to move any user to the trash by their ID.
+// The "policy" query parameter decides what happens to their posts and comments:
+// "cascade" trashes them with the user, "reassign" transfers them to the user "reassign_to",
+// and "anonymize" moves them to the deleted user placeholder. With dry_run=true
+// nothing is deleted and the affected post and comment counts are returned.
+// Users whose role grants permissions the caller lacks cannot be deleted.
+// Requires the users.manage permission.
 func DeleteUserAsAdmin(c *gin.Context) {
+	caller := c.MustGet("user").(models.User)
 	targetUserIDStr := c.Param("id")
 	targetUserID, err := strconv.ParseUint(targetUserIDStr, 10, 32)
 	if err != nil {
@@ -94,6 +251,26 @@ func DeleteUserAsAdmin(c *gin.Context) {
 		return
 	}
 
+	policy, ok := adminDeletionPolicies[c.Query("policy")]
+	if !ok {
+		c.JSON(400, gin.H{"message": "A deletion policy is required: 'cascade', 'reassign' or 'anonymize'."})
+		return
+	}
+
+	var reassignTo uint64
+	if policy == models.ContentPolicyReassign {
+		reassignTo, err = strconv.ParseUint(c.Query("reassign_to"), 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"message": "The 'reassign' policy needs a valid reassign_to user ID."})
+			return
+		}
+	}
+
+	if uint(targetUserID) == caller.Id {
+		c.JSON(403, gin.H{"message": "Admins cannot delete their own account via this endpoint."})
+		return
+	}
+
 	var user models.User
 	if err := database.DB.First(&user, targetUserID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -104,124 +281,245 @@ func DeleteUserAsAdmin(c *gin.Context) {
 		c.JSON(500, gin.H{"message": "Failed to delete user."})
 		return
 	}
+	if !checkRoleWithinCaller(c, caller, user.Role) {
+		return
+	}
 
-	// OPTIONAL: Prevent admin from deleting themselves
-	// currentUserID := c.MustGet("userID").(string) // Assuming userID is stored as string
-	// if strconv.ParseUint(currentUserID, 10, 32) == targetUserID {
-	//     c.JSON(403, gin.H{"message": "Admins cannot delete their own account via this endpoint."})
-	//     return
-	// }
-
-	// Delete the user
-	if err := database.DB.Delete(&user).Error; err != nil {
+	var counts services.AccountContentCounts
+	dryRun := c.Query("dry_run") == "true"
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if counts, err = services.CountAccountContent(tx, user.Id); err != nil {
+			return err
+		}
+		if err := services.DeleteAccount(tx, user, policy, uint(reassignTo)); err != nil {
+			return err
+		}
+		// A dry run performs the whole deletion and then rolls it back, so it
+		// fails exactly where the real deletion would.
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		if errors.Is(err, services.ErrInvalidReassignTarget) {
+			c.JSON(400, gin.H{"message": "The user to reassign content to does not exist or is the user being deleted."})
+			return
+		}
+		if errors.Is(err, services.ErrCannotDeletePlaceholder) {
+			c.JSON(403, gin.H{"message": "The deleted user placeholder cannot be deleted."})
+			return
+		}
 		log.Printf("Admin: Database error deleting user %d: %v\n", targetUserID, err)
 		c.JSON(500, gin.H{"message": "Failed to delete user."})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "User deleted successfully!"})
+	if dryRun {
+		c.JSON(200, gin.H{"message": "Dry run: nothing was deleted.", "dry_run": true, "affected": counts})
+		return
+	}
+	services.ResetLoginFailures(user.Email)
+	c.JSON(200, gin.H{"message": "User moved to the trash.", "affected": counts})
+}
+
+// ForceLogoutUserAsAdmin revokes every session of a user, logging them out on all devices.
+// Users whose role grants permissions the caller lacks cannot be logged out.
+// Requires the users.manage permission.
+func ForceLogoutUserAsAdmin(c *gin.Context) {
+	caller := c.MustGet("user").(models.User)
+	targetUserIDStr := c.Param("id")
+	targetUserID, err := strconv.ParseUint(targetUserIDStr, 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid user ID format."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, targetUserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "User not found."})
+			return
+		}
+		log.Printf("Admin: Database error finding user %d for force logout: %v\n", targetUserID, err)
+		c.JSON(500, gin.H{"message": "Failed to log out user."})
+		return
+	}
+	if !checkRoleWithinCaller(c, caller, user.Role) {
+		return
+	}
+
+	if err := services.RevokeUserSessions(database.DB, user.Id, ""); err != nil {
+		log.Printf("Admin: Database error revoking sessions of user %d: %v\n", targetUserID, err)
+		c.JSON(500, gin.H{"message": "Failed to log out user due to database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User logged out of all sessions successfully!"})
 }
 
 // --- Admin Content Approval (Blog Posts) ---
 
-// GetPendingPostsForAdmin retrieves all posts that are not yet approved.
-// Requires AdminMiddleware.
+// pendingPostsQuery selects the posts waiting for review that match the
+// filters of GetPendingPostsForAdmin. It returns false when the request was rejected.
+func pendingPostsQuery(c *gin.Context) (*gorm.DB, bool) {
+	// Fetch posts that have been submitted for review
+	query := adminSearch(c, database.DB.Model(&models.Blog{}).Where("status = ?", models.PostStatusSubmitted), "title")
+	return adminFilters(c, query, "user_id", "created_at")
+}
+
+// GetPendingPostsForAdmin retrieves the posts waiting for review a page at a
+// time. q searches titles; author, from and to filter by author and creation date.
+// Requires the posts.approve permission.
 func GetPendingPostsForAdmin(c *gin.Context) {
 	var posts []models.Blog
-	// Fetch posts where IsApproved is false
-	result := database.DB.Where("is_approved = ?", false).Order("created_at desc").Preload("User").Find(&posts)
-
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		log.Printf("Admin: Database error retrieving pending posts: %v\n", result.Error)
-		c.JSON(500, gin.H{"message": "Failed to retrieve pending posts."})
+	query, ok := pendingPostsQuery(c)
+	if !ok {
+		return
+	}
+	meta, ok := paginateAdminList(c, query.Order("created_at desc, id desc"), &posts, "pending posts", "User")
+	if !ok {
 		return
 	}
 
-	c.JSON(200, gin.H{"data": posts})
+	c.JSON(200, gin.H{"data": posts, "meta": meta})
 }
 
-// ApprovePostAsAdmin updates a post's status to approved.
-// Requires AdminMiddleware.
+// ApprovePostAsAdmin approves a submitted post. It is published right away,
+// or by the scheduler once its publish_at time has come. An optional
+// {"note": "..."} body is kept as the reviewer note. The If-Match header must
+// carry the ETag of the version that was reviewed.
+// Requires the posts.approve permission.
 func ApprovePostAsAdmin(c *gin.Context) {
-	postIDStr := c.Param("id")
-	postID, err := strconv.ParseUint(postIDStr, 10, 32)
-	if err != nil {
-		c.JSON(400, gin.H{"message": "Invalid post ID format."})
+	reviewerID := c.MustGet("userID").(uint)
+	note, ok := bindReviewNote(c)
+	if !ok {
 		return
 	}
 
 	var post models.Blog
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{"message": "Post not found."})
-			return
-		}
-		log.Printf("Admin: Database error finding post %d for approval: %v\n", postID, err)
-		c.JSON(500, gin.H{"message": "Failed to approve post."})
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+	if !checkPostIfMatch(c, post) {
 		return
 	}
 
-	post.IsApproved = true // Set to approved
-	if err := database.DB.Save(&post).Error; err != nil {
-		log.Printf("Admin: Database error approving post %d: %v\n", postID, err)
-		c.JSON(500, gin.H{"message": "Failed to approve post due to database error."})
+	original := post
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.ApprovePost(tx, &post, reviewerID, note)
+	})
+	if err != nil {
+		respondTransitionError(c, err, original, models.PostStatusApproved)
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Post approved successfully!", "post": post})
+	setPostETag(c, post)
+	if post.Status == models.PostStatusApproved {
+		c.JSON(200, gin.H{"message": "Post approved! It will be published at " + post.PublishAt.Format(time.RFC3339) + ".", "post": post})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Post approved and published successfully!", "post": post})
 }
 
-// RejectPostAsAdmin updates a post's status (e.g., marks it as rejected or deletes it).
-// Here, we'll delete it upon rejection, but you could implement a "rejected" status instead.
-// Requires AdminMiddleware.
+// RejectPostAsAdmin sends a submitted post back to its author with changes
+// requested. The {"note": "..."} body is required and tells the author what to
+// fix before they resubmit. The If-Match header must carry the ETag of the
+// version that was reviewed.
+// Requires the posts.approve permission.
 func RejectPostAsAdmin(c *gin.Context) {
-	postIDStr := c.Param("id")
-	postID, err := strconv.ParseUint(postIDStr, 10, 32)
+	reviewerID := c.MustGet("userID").(uint)
+	note, ok := bindReviewNote(c)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(note) == "" {
+		c.JSON(400, gin.H{"message": "Please add a note telling the author what to change."})
+		return
+	}
+
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+	if !checkPostIfMatch(c, post) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.TransitionPost(tx, &post, models.PostStatusChangesRequested, reviewerID, note, true)
+	})
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Invalid post ID format."})
+		respondTransitionError(c, err, post, models.PostStatusChangesRequested)
+		return
+	}
+
+	setPostETag(c, post)
+	c.JSON(200, gin.H{"message": "Changes requested. The author can revise and resubmit the post.", "post": post})
+}
+
+// ArchivePostAsAdmin unpublishes a published post. An optional {"note": "..."}
+// body is kept as the reviewer note. The If-Match header must carry the post's ETag.
+// Requires the posts.moderate permission.
+func ArchivePostAsAdmin(c *gin.Context) {
+	moderatorID := c.MustGet("userID").(uint)
+	note, ok := bindReviewNote(c)
+	if !ok {
 		return
 	}
 
 	var post models.Blog
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{"message": "Post not found."})
-			return
-		}
-		log.Printf("Admin: Database error finding post %d for rejection: %v\n", postID, err)
-		c.JSON(500, gin.H{"message": "Failed to reject post."})
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+	if !checkPostIfMatch(c, post) {
 		return
 	}
 
-	// Option 1: Delete the post upon rejection
-	if err := database.DB.Delete(&post).Error; err != nil {
-		log.Printf("Admin: Database error deleting post %d upon rejection: %v\n", postID, err)
-		c.JSON(500, gin.H{"message": "Failed to delete post upon rejection."})
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.TransitionPost(tx, &post, models.PostStatusArchived, moderatorID, note, true)
+	})
+	if err != nil {
+		respondTransitionError(c, err, post, models.PostStatusArchived)
 		return
 	}
-	c.JSON(200, gin.H{"message": "Post rejected and deleted successfully!"})
+
+	setPostETag(c, post)
+	c.JSON(200, gin.H{"message": "Post archived.", "post": post})
 }
 
 // --- Admin Content Approval (Comments) ---
 
-// GetPendingCommentsForAdmin retrieves all comments that are not yet approved.
-// Requires AdminMiddleware.
-func GetPendingCommentsForAdmin(c *gin.Context) {
-	var comments []models.Comment
+// pendingCommentsQuery selects the comments waiting for approval that match
+// the filters of GetPendingCommentsForAdmin. It returns false when the request
+// was rejected.
+func pendingCommentsQuery(c *gin.Context) (*gorm.DB, bool) {
 	// Fetch comments where IsApproved is false
-	result := database.DB.Where("is_approved = ?", false).Order("created_at desc").Preload("User").Preload("Blog").Find(&comments)
+	query := adminSearch(c, database.DB.Model(&models.Comment{}).Where("is_approved = ?", false), "content")
+	return adminFilters(c, query, "user_id", "created_at")
+}
 
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		log.Printf("Admin: Database error retrieving pending comments: %v\n", result.Error)
-		c.JSON(500, gin.H{"message": "Failed to retrieve pending comments."})
+// GetPendingCommentsForAdmin retrieves the comments that are not yet approved
+// a page at a time. q searches comment text; author, from and to filter by
+// author and creation date.
+// Requires the comments.moderate permission.
+func GetPendingCommentsForAdmin(c *gin.Context) {
+	var comments []models.Comment
+	query, ok := pendingCommentsQuery(c)
+	if !ok {
+		return
+	}
+	meta, ok := paginateAdminList(c, query.Order("created_at desc, id desc"), &comments, "pending comments", "User", "Blog")
+	if !ok {
 		return
 	}
 
-	c.JSON(200, gin.H{"data": comments})
+	c.JSON(200, gin.H{"data": comments, "meta": meta})
 }
 
 // ApproveCommentAsAdmin updates a comment's status to approved.
-// Requires AdminMiddleware.
+// Requires the comments.moderate permission.
 func ApproveCommentAsAdmin(c *gin.Context) {
 	commentIDStr := c.Param("id")
 	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
@@ -251,9 +549,8 @@ func ApproveCommentAsAdmin(c *gin.Context) {
 	c.JSON(200, gin.H{"message": "Comment approved successfully!", "comment": comment})
 }
 
-// RejectCommentAsAdmin updates a comment's status (e.g., marks it as rejected or deletes it).
-// Here, we'll delete it upon rejection.
-// Requires AdminMiddleware.
+// RejectCommentAsAdmin rejects a comment by moving it to the trash.
+// Requires the comments.moderate permission.
 func RejectCommentAsAdmin(c *gin.Context) {
 	commentIDStr := c.Param("id")
 	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
@@ -273,36 +570,62 @@ func RejectCommentAsAdmin(c *gin.Context) {
 		return
 	}
 
-	// Delete the comment upon rejection
+	// Move the comment to the trash upon rejection
 	if err := database.DB.Delete(&comment).Error; err != nil {
 		log.Printf("Admin: Database error deleting comment %d upon rejection: %v\n", commentID, err)
 		c.JSON(500, gin.H{"message": "Failed to delete comment upon rejection."})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Comment rejected and deleted successfully!"})
+	c.JSON(200, gin.H{"message": "Comment rejected and moved to the trash."})
 }
 
 // --- General Admin Content Moderation (Existing functions, kept and enhanced) ---
 
-// GetAllCommentsForAdmin retrieves all comments in the system, regardless of approval status.
-// This endpoint requires the AdminMiddleware.
+// commentsQuery selects the comments that match the filters of
+// GetAllCommentsForAdmin. It returns false when the request was rejected.
+func commentsQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := adminSearch(c, database.DB.Model(&models.Comment{}), "content")
+	if raw := c.Query("approved"); raw != "" {
+		approved, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(400, gin.H{"message": "approved must be true or false."})
+			return nil, false
+		}
+		query = query.Where("is_approved = ?", approved)
+	}
+	if raw := c.Query("post"); raw != "" {
+		postID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"message": "post must be a post ID."})
+			return nil, false
+		}
+		query = query.Where("blog_id = ?", postID)
+	}
+	return adminFilters(c, query, "user_id", "created_at")
+}
+
+// GetAllCommentsForAdmin retrieves the comments in the system a page at a time,
+// regardless of approval status. q searches comment text; approved (true or
+// false), post, author, from and to filter the list.
+// Requires the comments.moderate permission.
 func GetAllCommentsForAdmin(c *gin.Context) {
 	var comments []models.Comment
+	query, ok := commentsQuery(c)
+	if !ok {
+		return
+	}
 	// Preload User and Blog to get associated data easily for admin review
-	result := database.DB.Order("created_at desc").Preload("User").Preload("Blog").Find(&comments)
-
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		log.Printf("Admin: Database error retrieving all comments: %v\n", result.Error)
-		c.JSON(500, gin.H{"message": "Failed to retrieve comments."})
+	meta, ok := paginateAdminList(c, query.Order("created_at desc, id desc"), &comments, "comments", "User", "Blog")
+	if !ok {
 		return
 	}
 
-	c.JSON(200, gin.H{"data": comments})
+	c.JSON(200, gin.H{"data": comments, "meta": meta})
 }
 
 // DeleteCommentAsAdmin allows an admin to delete any comment by its ID.
-// This endpoint requires the AdminMiddleware.
+// Requires the comments.moderate permission.
 func DeleteCommentAsAdmin(c *gin.Context) {
 	commentIDStr := c.Param("id")
 	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
@@ -323,34 +646,52 @@ func DeleteCommentAsAdmin(c *gin.Context) {
 		return
 	}
 
-	// Delete the comment
+	// Move the comment to the trash (Comment is soft-deleted)
 	if err := database.DB.Delete(&comment).Error; err != nil {
 		log.Printf("Admin: Database error deleting comment %d: %v\n", commentID, err)
 		c.JSON(500, gin.H{"message": "Failed to delete comment."})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Comment deleted successfully!"})
+	c.JSON(200, gin.H{"message": "Comment moved to the trash."})
 }
 
-// GetAllPostsForAdmin retrieves all blog posts for admin review, including their authors.
-// This endpoint requires the AdminMiddleware.
+// postsQuery selects the posts that match the filters of GetAllPostsForAdmin.
+// It returns false when the request was rejected.
+func postsQuery(c *gin.Context) (*gorm.DB, bool) {
+	// Drafts stay private to their authors
+	query := adminSearch(c, database.DB.Model(&models.Blog{}).Where("status <> ?", models.PostStatusDraft), "title")
+	if status := c.Query("status"); status != "" {
+		if _, known := models.PostTransitions[status]; !known || status == models.PostStatusDraft {
+			c.JSON(400, gin.H{"message": "Unknown post status '" + status + "'."})
+			return nil, false
+		}
+		query = query.Where("status = ?", status)
+	}
+	return adminFilters(c, query, "user_id", "created_at")
+}
+
+// GetAllPostsForAdmin retrieves blog posts for admin review a page at a time,
+// including their authors. q searches titles; status, author, from and to
+// filter by lifecycle status, author and creation date.
+// Requires the posts.moderate permission.
 func GetAllPostsForAdmin(c *gin.Context) {
 	var posts []models.Blog
+	query, ok := postsQuery(c)
+	if !ok {
+		return
+	}
 	// Preload the User to show author details for each post
-	result := database.DB.Order("created_at desc").Preload("User").Find(&posts)
-
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		log.Printf("Admin: Database error retrieving all posts for admin: %v\n", result.Error)
-		c.JSON(500, gin.H{"message": "Failed to retrieve posts."})
+	meta, ok := paginateAdminList(c, query.Order("created_at desc, id desc"), &posts, "posts", "User")
+	if !ok {
 		return
 	}
 
-	c.JSON(200, gin.H{"data": posts})
+	c.JSON(200, gin.H{"data": posts, "meta": meta})
 }
 
 // DeletePostAsAdmin allows an admin to delete any post by its ID.
-// This endpoint requires the AdminMiddleware.
+// Requires the posts.moderate permission.
 func DeletePostAsAdmin(c *gin.Context) {
 	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -371,12 +712,41 @@ func DeletePostAsAdmin(c *gin.Context) {
 		return
 	}
 
-	// Delete the post
-	if err := database.DB.Delete(&post).Error; err != nil {
+	// Move the post and its comments to the trash
+	if err := services.TrashPost(database.DB, post); err != nil {
 		log.Printf("Admin: Database error deleting post %d: %v\n", postID, err)
 		c.JSON(500, gin.H{"message": "Failed to delete post."})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Post deleted successfully!"})
+	c.JSON(200, gin.H{"message": "Post moved to the trash."})
+}
+
+// UnlockUserAsAdmin clears the failed login count and any lockout on a user's account.
+// Requires the users.manage permission.
+func UnlockUserAsAdmin(c *gin.Context) {
+	targetUserIDStr := c.Param("id")
+	targetUserID, err := strconv.ParseUint(targetUserIDStr, 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid user ID format."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, targetUserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "User not found."})
+			return
+		}
+		log.Printf("Admin: Database error finding user %d for unlock: %v\n", targetUserID, err)
+		c.JSON(500, gin.H{"message": "Failed to unlock user."})
+		return
+	}
+
+	if err := services.ResetLoginFailures(user.Email); err != nil {
+		c.JSON(500, gin.H{"message": "Failed to unlock user."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User account unlocked successfully!"})
 }
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAPITokenLifetimeDays caps how long a personal access token may live.
+const maxAPITokenLifetimeDays = 365
+
+// GetMyAPITokens lists the authenticated user's active personal access tokens.
+func GetMyAPITokens(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var tokens []models.APIToken
+	result := database.DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at desc").Find(&tokens)
+	if result.Error != nil {
+		log.Printf("Database error listing API tokens for user %d: %v\n", userID, result.Error)
+		c.JSON(500, gin.H{"message": "Failed to retrieve your API tokens."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": tokens})
+}
+
+// CreateMyAPIToken issues a named, scoped personal access token. The raw token
+// is only returned in this response.
+func CreateMyAPIToken(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required,min=1"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Invalid data provided. Name and at least one scope are required."})
+		return
+	}
+
+	permissions, err := services.RolePermissions(user.Role)
+	if err != nil {
+		log.Printf("Database error loading permissions for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to create API token."})
+		return
+	}
+
+	seen := map[string]bool{}
+	scopes := []string{}
+	for _, scope := range input.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.IsValidScope(scope) {
+			c.JSON(400, gin.H{"message": "Invalid scope '" + scope + "'. Allowed scopes: " + strings.Join(models.ValidScopes, ", ") + "."})
+			return
+		}
+		if scope == models.ScopeAdmin && len(permissions) == 0 {
+			c.JSON(403, gin.H{"message": "Only users with admin permissions can create tokens with the 'admin' scope."})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if input.ExpiresInDays < 0 || input.ExpiresInDays > maxAPITokenLifetimeDays {
+		c.JSON(400, gin.H{"message": "expires_in_days must be between 1 and " + strconv.Itoa(maxAPITokenLifetimeDays) + " (or omitted for no expiry)."})
+		return
+	}
+	var expiresAt *time.Time
+	if input.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	raw, token, err := services.CreateAPIToken(user.Id, strings.TrimSpace(input.Name), scopes, expiresAt)
+	if err != nil {
+		log.Printf("Error creating API token for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to create API token."})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"message": "API token created! Copy it now, it will not be shown again.",
+		"token":   raw,
+		"data":    token,
+	})
+}
+
+// RevokeMyAPIToken revokes one of the authenticated user's personal access tokens.
+func RevokeMyAPIToken(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid token ID format."})
+		return
+	}
+
+	result := database.DB.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("Database error revoking API token %d for user %d: %v\n", tokenID, userID, result.Error)
+		c.JSON(500, gin.H{"message": "Failed to revoke API token."})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"message": "API token not found."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "API token revoked successfully!"})
+}
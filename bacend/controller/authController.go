@@ -3,14 +3,15 @@ package controller
 import (
 	"Gin-Blog-Website/database"
 	"Gin-Blog-Website/models"
-	"Gin-Blog-Website/utils"
+	"Gin-Blog-Website/services"
 
 	"fmt"
 	"log"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
-	"time" // Keep time import if used elsewhere, though not directly for User creation in this specific RegisterController
+	"time"
 
 	"github.com/dgrijalva/jwt-go" // Keep if Claims struct is used elsewhere or for clarity
 	"github.com/gin-gonic/gin"
@@ -21,6 +22,11 @@ func validateEmail(email string) bool {
 	return Re.MatchString(email)
 }
 
+// validatePassword enforces the minimum password length.
+func validatePassword(password string) bool {
+	return len(password) > 6
+}
+
 func RegisterController(c *gin.Context) {
 	var data map[string]interface{} // Using interface{} as per your current code
 	var userData models.User
@@ -57,7 +63,7 @@ func RegisterController(c *gin.Context) {
 	}
 
 	// Check if password is less than 6 characters
-	if len(password) <= 6 {
+	if !validatePassword(password) {
 		c.JSON(400, gin.H{"message": "Password must be greater than 6 characters!"})
 		return
 	}
@@ -69,8 +75,8 @@ func RegisterController(c *gin.Context) {
 		return
 	}
 
-	// Check if email already exists in database
-	database.DB.Where("email = ?", trimmedEmail).First(&userData)
+	// Check if email already exists in database, including accounts in the trash
+	database.DB.Unscoped().Where("email = ?", trimmedEmail).First(&userData)
 	if userData.Id != 0 { // Check if a user was found
 		c.JSON(400, gin.H{"message": "Email already exists!"})
 		return
@@ -82,7 +88,7 @@ func RegisterController(c *gin.Context) {
 		Phone:     phone,
 		Email:     trimmedEmail,
 		// NEW: Set the default role for new users
-		Role: "user",
+		Role: models.RoleUser,
 	}
 
 	user.SetPassword(password)
@@ -94,9 +100,38 @@ func RegisterController(c *gin.Context) {
 		return // Return after sending error response
 	}
 
+	// Send in the background; the user can ask for a new link if delivery fails.
+	go func(user models.User) {
+		if err := sendVerificationEmail(user); err != nil {
+			log.Printf("Error sending verification email to user %d: %v\n", user.Id, err)
+		}
+	}(user)
+
 	c.JSON(200, gin.H{
 		"user":    user,
-		"message": "Account created successfully!",
+		"message": "Account created successfully! Please check your email to verify your address.",
+	})
+}
+
+// loginFailedMessage is returned for every failed login so responses do not
+// reveal whether an email address has an account.
+const loginFailedMessage = "Invalid email or password."
+
+// timingDummyUser is compared against when the email is unknown, so a missing
+// account costs the same bcrypt work as a wrong password.
+var timingDummyUser = func() models.User {
+	var user models.User
+	user.SetPassword("timing-dummy-password")
+	return user
+}()
+
+// respondLoginLocked sends 429 with a Retry-After header for a locked out login.
+func respondLoginLocked(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(429, gin.H{
+		"message":     fmt.Sprintf("Too many failed login attempts. Please try again in %d seconds.", seconds),
+		"retry_after": seconds,
 	})
 }
 
@@ -108,34 +143,49 @@ func LoginController(c *gin.Context) {
 		return
 	}
 
+	email := strings.TrimSpace(data["email"])
+	if wait := services.LoginLockedFor(email, c.ClientIP()); wait > 0 {
+		respondLoginLocked(c, wait)
+		return
+	}
+
 	var user models.User
-	database.DB.Where("email = ?", data["email"]).First(&user)
+	database.DB.Where("email = ?", email).First(&user)
 	if user.Id == 0 { // If user.Id is 0, no user was found
-		c.JSON(404, gin.H{"message": "Email Address doesn't exist, Please, create an account!"})
+		timingDummyUser.ComparePassword(data["password"])
+	}
+
+	if user.Id == 0 || user.ComparePassword(data["password"]) != nil {
+		if wait := services.RecordLoginFailure(email, c.ClientIP()); wait > 0 {
+			respondLoginLocked(c, wait)
+			return
+		}
+		c.JSON(401, gin.H{"message": loginFailedMessage})
 		return
 	}
 
-	if err := user.ComparePassword(data["password"]); err != nil {
-		c.JSON(400, gin.H{"message": "Incorrect password!"})
+	// Users with two-factor authentication get a pending token instead of a
+	// session. Their failure count is only cleared once the second factor is
+	// verified, otherwise logging in with the password again would reset the
+	// lockout on code guesses.
+	if user.TOTPEnabled {
+		beginMFALogin(c, user)
 		return
 	}
 
-	token, err := utils.GenerateJwt(strconv.Itoa(int(user.Id)))
-	if err != nil {
-		log.Printf("Error generating JWT: %v\n", err) // Log the error for debugging
+	if err := startSession(c, user); err != nil {
+		log.Printf("Error starting session for user %d: %v\n", user.Id, err) // Log the error for debugging
 		c.JSON(500, gin.H{"message": "Internal server error"})
 		return
 	}
+	services.RecordLoginSuccess(email)
 
-	// Set the cookie
-	// domain parameter is empty "" which means it will default to the current domain (localhost in dev)
-	// You might want to explicitly set "localhost" for clarity if desired.
-	c.SetCookie("jwt", token, int(24*time.Hour.Seconds()), "/", "", false, true)
 	c.JSON(200, gin.H{
 		"message": "You have logged in successfully!",
 		"user":    user, // Returning user data on login might be a security concern depending on fields
 	})
 }
+
 func UserGetController(c *gin.Context) {
 	// The userID and user object are set by AuthMiddleware
 	userIDVal, exists := c.Get("userID")
@@ -173,16 +223,30 @@ func UserGetController(c *gin.Context) {
 		user = fetchedUser // Use the fetched user
 	}
 
+	permissions, err := services.RolePermissions(user.Role)
+	if err != nil {
+		log.Printf("Error loading permissions for role '%s': %v\n", user.Role, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve user data."})
+		return
+	}
+
 	// Important: Do not send password hash to the frontend
 	user.Password = nil
-	c.JSON(200, gin.H{"user": user})
+	c.JSON(200, gin.H{"user": user, "permissions": permissions})
 }
 
-// LogoutController handles user logout by clearing the JWT cookie
+// LogoutController handles user logout by revoking the refresh token family
+// on the server and clearing both auth cookies.
 func LogoutController(c *gin.Context) {
-	// Expire the JWT cookie
-	c.SetCookie("jwt", "", -1, "/", "localhost", false, true) // MaxAge -1 immediately expires it
+	if refreshToken, err := c.Cookie(refreshTokenCookie); err == nil && refreshToken != "" {
+		if err := services.RevokeRefreshToken(refreshToken); err != nil {
+			log.Printf("Error revoking refresh token on logout: %v\n", err)
+			c.JSON(500, gin.H{"message": "Logout failed due to server error."})
+			return
+		}
+	}
 
+	clearAuthCookies(c)
 	c.JSON(200, gin.H{"message": "Logout successful!"})
 }
 
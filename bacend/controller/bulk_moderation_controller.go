@@ -0,0 +1,423 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxBulkItems caps how many posts or comments one bulk action may touch.
+const maxBulkItems = 500
+
+// Per-item results of a bulk action besides the new state of the item.
+const (
+	// bulkUnchanged means the item was already in the requested state, so
+	// repeating a bulk action is harmless.
+	bulkUnchanged = "unchanged"
+	bulkNotFound  = "not_found"
+	// bulkConflict means the item cannot be moved to the requested state.
+	bulkConflict = "conflict"
+)
+
+// errBulkFailed rolls back a bulk action in which some item failed.
+var errBulkFailed = errors.New("bulk action failed for some items")
+
+// bulkInput is the body of the bulk moderation endpoints. It names the items
+// either by ID or with all_matching, which takes every item matching the
+// filters of the corresponding admin list, passed as query parameters.
+type bulkInput struct {
+	IDs         []uint `json:"ids"`
+	AllMatching bool   `json:"all_matching"`
+	Note        string `json:"note"`
+}
+
+func (input bulkInput) validate() map[string]string {
+	problems := map[string]string{}
+	switch {
+	case len(input.IDs) > 0 && input.AllMatching:
+		problems["ids"] = "Send either ids or all_matching, not both."
+	case len(input.IDs) == 0 && !input.AllMatching:
+		problems["ids"] = "Send the ids to act on, or all_matching to act on every item matching the filters."
+	case len(input.IDs) > maxBulkItems:
+		problems["ids"] = fmt.Sprintf("At most %d items can be changed at once.", maxBulkItems)
+	}
+	for _, id := range input.IDs {
+		if id == 0 {
+			problems["ids"] = "IDs must be positive."
+			break
+		}
+	}
+	return problems
+}
+
+// bulkResult is the outcome of a bulk action for one item.
+type bulkResult struct {
+	ID     uint   `json:"id"`
+	Result string `json:"result"`
+	// Status is the state of a post that was left as it was.
+	Status string `json:"status,omitempty"`
+	// Version is the post's version after the action, or its current version
+	// when it could not be changed.
+	Version uint `json:"version,omitempty"`
+}
+
+func (result bulkResult) failed() bool {
+	return result.Result == bulkNotFound || result.Result == bulkConflict
+}
+
+// bindBulkTargets reads the body of a bulk action and resolves the IDs it acts
+// on, in the order given, without duplicates. With all_matching the IDs come
+// from scope. It responds with 400/500 itself and returns false when the
+// request was rejected.
+func bindBulkTargets(c *gin.Context, what string, scope func(c *gin.Context) (*gorm.DB, bool)) (bulkInput, bool) {
+	var input bulkInput
+	if !bindStrictJSON(c, &input) {
+		return input, false
+	}
+
+	if input.AllMatching {
+		query, ok := scope(c)
+		if !ok {
+			return input, false
+		}
+		if err := query.Order("id asc").Limit(maxBulkItems+1).Pluck("id", &input.IDs).Error; err != nil {
+			log.Printf("Admin: Database error selecting %s for a bulk action: %v\n", what, err)
+			c.JSON(500, gin.H{"message": "Failed to select " + what + "."})
+			return input, false
+		}
+		if len(input.IDs) > maxBulkItems {
+			c.JSON(400, gin.H{"message": fmt.Sprintf("More than %d %s match. Narrow the filters and try again.", maxBulkItems, what)})
+			return input, false
+		}
+		return input, true
+	}
+
+	seen := make(map[uint]bool, len(input.IDs))
+	ids := input.IDs[:0]
+	for _, id := range input.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	input.IDs = ids
+	return input, true
+}
+
+// runBulkAction applies action to the items in ids within one transaction;
+// itemID returns the ID of an item. The items are locked, trashed ones
+// included, so nothing changes under the action. If any item is missing or in
+// a state the action cannot leave, no item is changed and the response is a
+// 409 listing what failed.
+func runBulkAction[T any](c *gin.Context, what string, verb string, ids []uint, itemID func(item *T) uint, action func(tx *gorm.DB, item *T) (bulkResult, error)) {
+	results := make([]bulkResult, 0, len(ids))
+	if len(ids) == 0 {
+		c.JSON(200, gin.H{"message": "No " + what + " match the filters.", "applied": true, "results": results, "summary": gin.H{}})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var items []T
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).Order("id asc").Find(&items).Error
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint]*T, len(items))
+		for i := range items {
+			byID[itemID(&items[i])] = &items[i]
+		}
+
+		failed := false
+		for _, id := range ids {
+			item, found := byID[id]
+			if !found {
+				results = append(results, bulkResult{ID: id, Result: bulkNotFound})
+				failed = true
+				continue
+			}
+			result, err := action(tx, item)
+			if err != nil {
+				return err
+			}
+			result.ID = id
+			results = append(results, result)
+			failed = failed || result.failed()
+		}
+		if failed {
+			return errBulkFailed
+		}
+		return nil
+	})
+
+	summary := map[string]int{}
+	for _, result := range results {
+		summary[result.Result]++
+	}
+	if errors.Is(err, errBulkFailed) {
+		c.JSON(409, gin.H{
+			"message": "Nothing was changed because some " + what + " could not be " + verb + ".",
+			"applied": false,
+			"results": results,
+			"summary": summary,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Admin: Database error in bulk action on %s: %v\n", what, err)
+		c.JSON(500, gin.H{"message": "Failed to update " + what + " due to database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": fmt.Sprintf("%d %s processed.", len(results), what),
+		"applied": true,
+		"results": results,
+		"summary": summary,
+	})
+}
+
+// runBulkPostAction runs action on the posts in ids, see runBulkAction.
+func runBulkPostAction(c *gin.Context, verb string, ids []uint, action func(tx *gorm.DB, post *models.Blog) (bulkResult, error)) {
+	runBulkAction(c, "posts", verb, ids, func(post *models.Blog) uint { return post.ID }, action)
+}
+
+// runBulkCommentAction runs action on the comments in ids, see runBulkAction.
+func runBulkCommentAction(c *gin.Context, verb string, ids []uint, action func(tx *gorm.DB, comment *models.Comment) (bulkResult, error)) {
+	runBulkAction(c, "comments", verb, ids, func(comment *models.Comment) uint { return comment.ID }, action)
+}
+
+// transitionPostInBulk moves post to status with transition, treating a post
+// that is already in one of the done states as unchanged and a trashed post
+// as missing. Illegal transitions and services.ErrPostModified are conflicts.
+func transitionPostInBulk(tx *gorm.DB, post *models.Blog, transition func(tx *gorm.DB, post *models.Blog) error, done ...string) (bulkResult, error) {
+	if post.DeletedAt.Valid {
+		return bulkResult{Result: bulkNotFound}, nil
+	}
+	for _, status := range done {
+		if post.Status == status {
+			return bulkResult{Result: bulkUnchanged, Status: post.Status, Version: post.Version}, nil
+		}
+	}
+	from, version := post.Status, post.Version
+	if err := transition(tx, post); err != nil {
+		if errors.Is(err, services.ErrIllegalTransition) || errors.Is(err, services.ErrPostModified) {
+			return bulkResult{Result: bulkConflict, Status: from, Version: version}, nil
+		}
+		return bulkResult{}, err
+	}
+	return bulkResult{Result: post.Status, Version: post.Version}, nil
+}
+
+// bulkApproveInput is the body of BulkApprovePostsAsAdmin. Every post comes
+// with the version the reviewer saw, as bulkInput's all_matching could
+// approve content nobody has looked at.
+type bulkApproveInput struct {
+	Posts []struct {
+		ID      uint `json:"id"`
+		Version uint `json:"version"`
+	} `json:"posts"`
+	Note string `json:"note"`
+}
+
+func (input bulkApproveInput) validate() map[string]string {
+	problems := map[string]string{}
+	if len(input.Posts) == 0 {
+		problems["posts"] = "Send the posts to approve as {\"id\", \"version\"} pairs."
+	} else if len(input.Posts) > maxBulkItems {
+		problems["posts"] = fmt.Sprintf("At most %d items can be changed at once.", maxBulkItems)
+	}
+	versions := make(map[uint]uint, len(input.Posts))
+	for _, post := range input.Posts {
+		if post.ID == 0 || post.Version == 0 {
+			problems["posts"] = "Every post needs a positive id and the version that was reviewed."
+			break
+		}
+		if seen, ok := versions[post.ID]; ok && seen != post.Version {
+			problems["posts"] = fmt.Sprintf("Post %d is listed with two different versions.", post.ID)
+			break
+		}
+		versions[post.ID] = post.Version
+	}
+	return problems
+}
+
+// --- Bulk Moderation (Posts) ---
+
+// BulkApprovePostsAsAdmin approves several submitted posts at once, publishing
+// those that are due, like ApprovePostAsAdmin. The body lists the posts as
+// {"posts": [{"id": 1, "version": 3}, ...]}, each with the version that was
+// reviewed; a post changed since then is a conflict, just like a stale
+// If-Match on ApprovePostAsAdmin. An optional note is kept as the reviewer
+// note. Posts that are already approved or published are reported as unchanged.
+// Requires the posts.approve permission.
+func BulkApprovePostsAsAdmin(c *gin.Context) {
+	reviewerID := c.MustGet("userID").(uint)
+	var input bulkApproveInput
+	if !bindStrictJSON(c, &input) {
+		return
+	}
+
+	versions := make(map[uint]uint, len(input.Posts))
+	ids := make([]uint, 0, len(input.Posts))
+	for _, post := range input.Posts {
+		if _, seen := versions[post.ID]; !seen {
+			versions[post.ID] = post.Version
+			ids = append(ids, post.ID)
+		}
+	}
+
+	runBulkPostAction(c, "approved", ids, func(tx *gorm.DB, post *models.Blog) (bulkResult, error) {
+		return transitionPostInBulk(tx, post, func(tx *gorm.DB, post *models.Blog) error {
+			if post.Version != versions[post.ID] {
+				return services.ErrPostModified
+			}
+			return services.ApprovePost(tx, post, reviewerID, input.Note)
+		}, models.PostStatusApproved, models.PostStatusPublished)
+	})
+}
+
+// BulkRejectPostsAsAdmin requests changes to several submitted posts at once,
+// like RejectPostAsAdmin. The body names the posts with {"ids": [...]} or
+// {"all_matching": true}, which takes every post in the review queue matching
+// the filters of GetPendingPostsForAdmin, and must carry a note for the authors.
+// Requires the posts.approve permission.
+func BulkRejectPostsAsAdmin(c *gin.Context) {
+	reviewerID := c.MustGet("userID").(uint)
+	input, ok := bindBulkTargets(c, "posts", pendingPostsQuery)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(input.Note) == "" {
+		c.JSON(400, gin.H{"message": "Please add a note telling the authors what to change."})
+		return
+	}
+
+	runBulkPostAction(c, "sent back for changes", input.IDs, func(tx *gorm.DB, post *models.Blog) (bulkResult, error) {
+		return transitionPostInBulk(tx, post, func(tx *gorm.DB, post *models.Blog) error {
+			return services.TransitionPost(tx, post, models.PostStatusChangesRequested, reviewerID, input.Note, true)
+		}, models.PostStatusChangesRequested)
+	})
+}
+
+// publishedPostsQuery selects the published posts that match the filters of
+// GetAllPostsForAdmin.
+func publishedPostsQuery(c *gin.Context) (*gorm.DB, bool) {
+	query, ok := postsQuery(c)
+	if !ok {
+		return nil, false
+	}
+	return query.Where("status = ?", models.PostStatusPublished), true
+}
+
+// BulkArchivePostsAsAdmin unpublishes several published posts at once. The
+// body names the posts with {"ids": [...]} or {"all_matching": true}, which
+// takes every published post matching the filters of GetAllPostsForAdmin. An
+// optional note is kept as the reviewer note.
+// Requires the posts.moderate permission.
+func BulkArchivePostsAsAdmin(c *gin.Context) {
+	moderatorID := c.MustGet("userID").(uint)
+	input, ok := bindBulkTargets(c, "posts", publishedPostsQuery)
+	if !ok {
+		return
+	}
+
+	runBulkPostAction(c, "archived", input.IDs, func(tx *gorm.DB, post *models.Blog) (bulkResult, error) {
+		return transitionPostInBulk(tx, post, func(tx *gorm.DB, post *models.Blog) error {
+			return services.TransitionPost(tx, post, models.PostStatusArchived, moderatorID, input.Note, true)
+		}, models.PostStatusArchived)
+	})
+}
+
+// BulkDeletePostsAsAdmin moves several posts and their comments to the trash
+// at once. The body names the posts with {"ids": [...]} or
+// {"all_matching": true}, which takes every post matching the filters of
+// GetAllPostsForAdmin. Posts already in the trash are reported as unchanged.
+// Requires the posts.moderate permission.
+func BulkDeletePostsAsAdmin(c *gin.Context) {
+	input, ok := bindBulkTargets(c, "posts", postsQuery)
+	if !ok {
+		return
+	}
+
+	runBulkPostAction(c, "deleted", input.IDs, func(tx *gorm.DB, post *models.Blog) (bulkResult, error) {
+		if post.DeletedAt.Valid {
+			return bulkResult{Result: bulkUnchanged}, nil
+		}
+		if err := services.TrashPost(tx, *post); err != nil {
+			return bulkResult{}, err
+		}
+		return bulkResult{Result: "trashed"}, nil
+	})
+}
+
+// --- Bulk Moderation (Comments) ---
+
+// BulkApproveCommentsAsAdmin approves several comments at once. The body names
+// the comments with {"ids": [...]} or {"all_matching": true}, which takes every
+// comment matching the filters of GetPendingCommentsForAdmin. Comments that are
+// already approved are reported as unchanged.
+// Requires the comments.moderate permission.
+func BulkApproveCommentsAsAdmin(c *gin.Context) {
+	input, ok := bindBulkTargets(c, "comments", pendingCommentsQuery)
+	if !ok {
+		return
+	}
+
+	runBulkCommentAction(c, "approved", input.IDs, func(tx *gorm.DB, comment *models.Comment) (bulkResult, error) {
+		if comment.DeletedAt.Valid {
+			return bulkResult{Result: bulkNotFound}, nil
+		}
+		if comment.IsApproved {
+			return bulkResult{Result: bulkUnchanged}, nil
+		}
+		if err := tx.Model(comment).Update("is_approved", true).Error; err != nil {
+			return bulkResult{}, err
+		}
+		return bulkResult{Result: "approved"}, nil
+	})
+}
+
+// trashCommentsInBulk moves the comments in ids to the trash. Comments already
+// in the trash are reported as unchanged.
+func trashCommentsInBulk(c *gin.Context, ids []uint, verb string) {
+	runBulkCommentAction(c, verb, ids, func(tx *gorm.DB, comment *models.Comment) (bulkResult, error) {
+		if comment.DeletedAt.Valid {
+			return bulkResult{Result: bulkUnchanged}, nil
+		}
+		if err := tx.Delete(comment).Error; err != nil {
+			return bulkResult{}, err
+		}
+		return bulkResult{Result: "trashed"}, nil
+	})
+}
+
+// BulkRejectCommentsAsAdmin rejects several comments at once by moving them to
+// the trash. The body names the comments as for BulkApproveCommentsAsAdmin.
+// Requires the comments.moderate permission.
+func BulkRejectCommentsAsAdmin(c *gin.Context) {
+	input, ok := bindBulkTargets(c, "comments", pendingCommentsQuery)
+	if !ok {
+		return
+	}
+	trashCommentsInBulk(c, input.IDs, "rejected")
+}
+
+// BulkDeleteCommentsAsAdmin moves several comments to the trash at once. The
+// body names the comments with {"ids": [...]} or {"all_matching": true}, which
+// takes every comment matching the filters of GetAllCommentsForAdmin.
+// Requires the comments.moderate permission.
+func BulkDeleteCommentsAsAdmin(c *gin.Context) {
+	input, ok := bindBulkTargets(c, "comments", commentsQuery)
+	if !ok {
+		return
+	}
+	trashCommentsInBulk(c, input.IDs, "deleted")
+}
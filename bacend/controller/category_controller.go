@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxCategoryNameLength is the longest category name accepted.
+const maxCategoryNameLength = 100
+
+var (
+	errCategorySlugTaken  = errors.New("category slug is already in use")
+	errCategoryParent     = errors.New("parent category does not exist")
+	errCategoryParentLoop = errors.New("category cannot be moved below itself")
+)
+
+// categoryInput is the body of CreateCategoryAsAdmin and UpdateCategoryAsAdmin.
+// On update, fields left out are not changed.
+type categoryInput struct {
+	Name        *string        `json:"name"`
+	Slug        *string        `json:"slug"`
+	Description *string        `json:"description"`
+	ParentID    nullable[uint] `json:"parent_id"`
+}
+
+func (input categoryInput) validate() map[string]string {
+	problems := map[string]string{}
+	if input.Name != nil {
+		length := utf8.RuneCountInString(strings.TrimSpace(*input.Name))
+		if length < 1 || length > maxCategoryNameLength {
+			problems["name"] = fmt.Sprintf("Name must be between 1 and %d characters.", maxCategoryNameLength)
+		}
+	}
+	if input.Slug != nil && !utils.ValidSlug(*input.Slug) {
+		problems["slug"] = fmt.Sprintf("Slug must be at most %d lowercase letters, digits and single hyphens.", utils.MaxSlugLength)
+	}
+	return problems
+}
+
+// applyCategoryInput copies input onto category and checks the slug and
+// parent against the other categories.
+func applyCategoryInput(tx *gorm.DB, category *models.Category, input categoryInput) error {
+	if input.Name != nil {
+		category.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		category.Description = *input.Description
+	}
+	if input.Slug != nil {
+		category.Slug = *input.Slug
+	} else if category.Slug == "" {
+		category.Slug = utils.Slugify(category.Name)
+		if category.Slug == "" {
+			category.Slug = "category"
+		}
+	}
+
+	var taken int64
+	if err := tx.Model(&models.Category{}).Where("slug = ? AND id <> ?", category.Slug, category.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errCategorySlugTaken
+	}
+
+	if input.ParentID.Set {
+		category.ParentID = input.ParentID.Value
+	}
+	if category.ParentID != nil {
+		var parent models.Category
+		if err := tx.First(&parent, *category.ParentID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errCategoryParent
+			}
+			return err
+		}
+		if category.ID != 0 {
+			loop, err := services.IsInCategoryTree(tx, category.ID, parent.ID)
+			if err != nil {
+				return err
+			}
+			if loop {
+				return errCategoryParentLoop
+			}
+		}
+	}
+	return nil
+}
+
+// respondCategoryError maps the errors of applyCategoryInput to responses.
+func respondCategoryError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, errCategorySlugTaken):
+		c.JSON(409, gin.H{"message": "Another category already uses this slug."})
+	case errors.Is(err, errCategoryParent):
+		c.JSON(400, gin.H{"message": "Parent category not found."})
+	case errors.Is(err, errCategoryParentLoop):
+		c.JSON(400, gin.H{"message": "A category cannot be moved below itself or one of its subcategories."})
+	default:
+		log.Printf("Admin: Database error trying to %s category: %v\n", action, err)
+		c.JSON(500, gin.H{"message": "Failed to " + action + " category due to database error."})
+	}
+}
+
+// findCategory loads the category named by the :id parameter, responding with
+// 400/404/500 itself. It returns false when the request was rejected.
+func findCategory(c *gin.Context, category *models.Category) bool {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid category ID format."})
+		return false
+	}
+	if err := database.DB.First(category, categoryID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Category not found."})
+			return false
+		}
+		log.Printf("Database error finding category %d: %v\n", categoryID, err)
+		c.JSON(500, gin.H{"message": "Database error retrieving category."})
+		return false
+	}
+	return true
+}
+
+// checkPostCategory makes sure the category a post is being filed under
+// exists. It responds with 400/500 itself and returns false when the request
+// was rejected. A nil categoryID leaves the post uncategorized.
+func checkPostCategory(c *gin.Context, categoryID *uint) bool {
+	if categoryID == nil {
+		return true
+	}
+	var count int64
+	if err := database.DB.Model(&models.Category{}).Where("id = ?", *categoryID).Count(&count).Error; err != nil {
+		log.Printf("Database error finding category %d: %v\n", *categoryID, err)
+		c.JSON(500, gin.H{"message": "Database error retrieving category."})
+		return false
+	}
+	if count == 0 {
+		c.JSON(400, gin.H{"message": "Category not found."})
+		return false
+	}
+	return true
+}
+
+// GetCategories lists every category. Clients build the tree from parent_id.
+func GetCategories(c *gin.Context) {
+	var categories []models.Category
+	if err := database.DB.Order("name asc").Find(&categories).Error; err != nil {
+		log.Printf("Database error retrieving categories: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve categories."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": categories})
+}
+
+// GetPostsByCategory lists the published posts of a category and its
+// subcategories, with the filters and paging of GetAllPost.
+func GetPostsByCategory(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.Where("slug = ?", c.Param("slug")).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Category not found."})
+			return
+		}
+		log.Printf("Database error finding category %q: %v\n", c.Param("slug"), err)
+		c.JSON(500, gin.H{"message": "Database error retrieving category."})
+		return
+	}
+
+	listPublishedPosts(c, services.PostCategoryFilter(category.ID))
+}
+
+// GetPostsByTag lists the published posts with a tag, with the filters and
+// paging of GetAllPost.
+func GetPostsByTag(c *gin.Context) {
+	var tag models.Tag
+	if err := database.DB.Where("name = ?", utils.Slugify(c.Param("tag"))).First(&tag).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Tag not found."})
+			return
+		}
+		log.Printf("Database error finding tag %q: %v\n", c.Param("tag"), err)
+		c.JSON(500, gin.H{"message": "Database error retrieving tag."})
+		return
+	}
+
+	listPublishedPosts(c, services.PostTagFilter(tag.Name))
+}
+
+// CreateCategoryAsAdmin creates a category. The slug is derived from the name
+// unless given.
+// Requires the categories.manage permission.
+func CreateCategoryAsAdmin(c *gin.Context) {
+	var input categoryInput
+	if !bindStrictJSON(c, &input) {
+		return
+	}
+	if input.Name == nil {
+		c.JSON(400, gin.H{"message": "Category name is required."})
+		return
+	}
+
+	var category models.Category
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyCategoryInput(tx, &category, input); err != nil {
+			return err
+		}
+		return tx.Create(&category).Error
+	})
+	if err != nil {
+		respondCategoryError(c, err, "create")
+		return
+	}
+
+	c.JSON(201, gin.H{"message": "Category created successfully!", "category": category})
+}
+
+// UpdateCategoryAsAdmin renames, describes or moves a category. Changing the
+// slug breaks links to the category's old address.
+// Requires the categories.manage permission.
+func UpdateCategoryAsAdmin(c *gin.Context) {
+	var category models.Category
+	if !findCategory(c, &category) {
+		return
+	}
+
+	var input categoryInput
+	if !bindStrictJSON(c, &input) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyCategoryInput(tx, &category, input); err != nil {
+			return err
+		}
+		return tx.Select("name", "slug", "description", "parent_id").Updates(&category).Error
+	})
+	if err != nil {
+		respondCategoryError(c, err, "update")
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Category updated successfully!", "category": category})
+}
+
+// DeleteCategoryAsAdmin deletes a category. Its subcategories and posts move
+// up to its parent category, or become top-level and uncategorized.
+// Requires the categories.manage permission.
+func DeleteCategoryAsAdmin(c *gin.Context) {
+	var category models.Category
+	if !findCategory(c, &category) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.DeleteCategory(tx, category)
+	})
+	if err != nil {
+		log.Printf("Admin: Database error deleting category %d: %v\n", category.ID, err)
+		c.JSON(500, gin.H{"message": "Failed to delete category due to database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Category deleted successfully!"})
+}
@@ -20,24 +20,23 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
+	if !requireVerifiedEmail(c) {
+		return
+	}
+
 	userIDVal, exists := c.Get("userID")
 	if !exists {
 		log.Println("Error: UserID not found in context for CreateComment. AuthMiddleware missing or failed.")
 		c.JSON(500, gin.H{"message": "Authentication context missing."})
 		return
 	}
-	userIDStr, ok := userIDVal.(string)
+	// UserID is stored as uint by AuthMiddleware
+	userID, ok := userIDVal.(uint)
 	if !ok {
-		log.Printf("Error: UserID in context is not a string, got %T\n", userIDVal)
+		log.Printf("Error: UserID in context is not a uint, got %T\n", userIDVal)
 		c.JSON(500, gin.H{"message": "Invalid user ID format in context."})
 		return
 	}
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		log.Printf("Error parsing userID '%s' from context to uint: %v\n", userIDStr, err)
-		c.JSON(500, gin.H{"message": "Invalid user ID format."})
-		return
-	}
 
 	var input struct {
 		Content string `json:"content" binding:"required"`
@@ -48,9 +47,21 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
+	// Only published posts can be commented on
+	var post models.Blog
+	if err := database.DB.Where("id = ? AND status = ?", blogID, models.PostStatusPublished).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Post not found."})
+			return
+		}
+		log.Printf("Database error finding post %d for comment: %v\n", blogID, err)
+		c.JSON(500, gin.H{"message": "Failed to create comment due to database error."})
+		return
+	}
+
 	comment := models.Comment{
 		Content:   input.Content,
-		UserID:    uint(userID),
+		UserID:    userID,
 		BlogID:    uint(blogID),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
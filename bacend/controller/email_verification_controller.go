@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/platform/mailer"
+	"Gin-Blog-Website/utils"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// emailVerificationTTL is how long a verification link stays valid.
+const emailVerificationTTL = 48 * time.Hour
+
+// sendVerificationEmail emails the user a signed link confirming their current address.
+func sendVerificationEmail(user models.User) error {
+	token, err := utils.GeneratePurposeToken(strconv.Itoa(int(user.Id)), utils.PurposeEmailVerify, user.Email, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Gin Blog email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address to start posting and commenting:\n%s\n\n"+
+			"This link expires in 48 hours.",
+			user.FirstName, frontendURL("/verify-email?token="+token)),
+	})
+}
+
+// requireVerifiedEmail aborts with 403 unless the authenticated user has verified
+// their email address. It returns false when the request was rejected.
+func requireVerifiedEmail(c *gin.Context) bool {
+	userVal, exists := c.Get("user")
+	if !exists {
+		log.Println("Error: User object not found in context. AuthMiddleware missing or failed.")
+		c.JSON(500, gin.H{"message": "Authentication context missing."})
+		return false
+	}
+	user, ok := userVal.(models.User)
+	if !ok {
+		log.Printf("Error: User in context is not of type models.User, got %T\n", userVal)
+		c.JSON(500, gin.H{"message": "Invalid user context type."})
+		return false
+	}
+
+	if !user.IsEmailVerified() {
+		c.JSON(403, gin.H{
+			"message": "Please verify your email address before posting or commenting.",
+			"code":    "email_unverified",
+		})
+		return false
+	}
+	return true
+}
+
+// VerifyEmailController confirms a user's email address from a verification link token.
+func VerifyEmailController(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Verification token is required."})
+		return
+	}
+
+	claims, err := utils.ParsePurposeToken(input.Token, utils.PurposeEmailVerify)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "This verification link is invalid or has expired."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.Issuer).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(400, gin.H{"message": "This verification link is invalid or has expired."})
+			return
+		}
+		log.Printf("Database error finding user %s for email verification: %v\n", claims.Issuer, err)
+		c.JSON(500, gin.H{"message": "Failed to verify email due to server error."})
+		return
+	}
+
+	// The link only verifies the address it was sent to.
+	if user.Email != claims.Email {
+		c.JSON(400, gin.H{"message": "This verification link is for a different email address."})
+		return
+	}
+
+	if user.IsEmailVerified() {
+		c.JSON(200, gin.H{"message": "Email address is already verified."})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+		log.Printf("Database error marking email verified for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to verify email due to server error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Email address verified successfully!"})
+}
+
+// ResendVerificationEmailController sends a fresh verification link to the authenticated user.
+func ResendVerificationEmailController(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	if user.IsEmailVerified() {
+		c.JSON(400, gin.H{"message": "Email address is already verified."})
+		return
+	}
+
+	if err := sendVerificationEmail(user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to send verification email."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Verification email sent! Please check your inbox."})
+}
@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"Gin-Blog-Website/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSController publishes the public keys used to sign blog tokens so other
+// services can verify them. Symmetric (HS256) keys are never included.
+func JWKSController(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, utils.JWKS())
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/platform/oidc"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// oidcStateCookie binds an in-flight login to the browser that started it.
+	oidcStateCookie = "oidc_state"
+	oidcLoginTTL    = 10 * time.Minute
+)
+
+var (
+	errOIDCEmailUnverified = errors.New("identity provider did not return a verified email address")
+	errOIDCAccountDeleted  = errors.New("the account for this email address has been deleted")
+)
+
+// GetOIDCProviders lists the configured social login providers.
+func GetOIDCProviders(c *gin.Context) {
+	c.JSON(200, gin.H{"data": oidc.ProviderNames()})
+}
+
+// OIDCLoginController starts an authorization code + PKCE login with the provider
+// and redirects the browser to it.
+func OIDCLoginController(c *gin.Context) {
+	provider, ok := oidc.GetProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(404, gin.H{"message": "Unknown login provider."})
+		return
+	}
+
+	state, errState := utils.GenerateOpaqueToken()
+	nonce, errNonce := utils.GenerateOpaqueToken()
+	verifier, errVerifier := utils.GenerateOpaqueToken()
+	if errState != nil || errNonce != nil || errVerifier != nil {
+		log.Println("Error generating OIDC login secrets")
+		c.JSON(500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Printf("Error building OIDC authorization URL for %s: %v\n", provider.Name, err)
+		c.JSON(502, gin.H{"message": "Login provider is unavailable, please try again later."})
+		return
+	}
+
+	err = database.DB.Create(&models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	}).Error
+	if err != nil {
+		log.Printf("Database error storing OIDC login state: %v\n", err)
+		c.JSON(500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	c.SetCookie(oidcStateCookie, state, int(oidcLoginTTL.Seconds()), "/api/auth/oidc", "", false, true)
+	c.Redirect(302, authURL)
+}
+
+// OIDCCallbackController finishes an OIDC login: it validates the state, exchanges
+// the code, verifies the ID token, finds or links the user and starts a session
+// exactly like LoginController. The browser is redirected back to the frontend.
+func OIDCCallbackController(c *gin.Context) {
+	fail := func(reason string) {
+		c.Redirect(302, frontendURL("/login?error="+url.QueryEscape(reason)))
+	}
+
+	provider, ok := oidc.GetProvider(c.Param("provider"))
+	if !ok {
+		c.JSON(404, gin.H{"message": "Unknown login provider."})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		log.Printf("OIDC provider %s returned error: %s\n", provider.Name, providerErr)
+		fail("oidc_denied")
+		return
+	}
+
+	state := c.Query("state")
+	cookieState, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/api/auth/oidc", "", false, true)
+	if state == "" || state != cookieState {
+		fail("oidc_state_mismatch")
+		return
+	}
+
+	// Load and consume the login state in one step so it cannot be replayed.
+	var loginState models.OIDCLoginState
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ? AND provider = ?", utils.HashToken(state), provider.Name).First(&loginState).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&loginState).Error
+	})
+	if err != nil || time.Now().After(loginState.ExpiresAt) {
+		fail("oidc_state_expired")
+		return
+	}
+
+	tokens, err := provider.Exchange(c.Request.Context(), c.Query("code"), loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange failed: %v\n", err)
+		fail("oidc_failed")
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(c.Request.Context(), tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC ID token from %s rejected: %v\n", provider.Name, err)
+		fail("oidc_failed")
+		return
+	}
+
+	user, err := findOrLinkOIDCUser(provider.Name, claims)
+	if err != nil {
+		if errors.Is(err, errOIDCEmailUnverified) {
+			fail("oidc_email_unverified")
+			return
+		}
+		if errors.Is(err, errOIDCAccountDeleted) {
+			fail("oidc_account_deleted")
+			return
+		}
+		log.Printf("Error linking OIDC identity %s/%s: %v\n", provider.Name, claims.Subject, err)
+		fail("oidc_failed")
+		return
+	}
+
+	// Two-factor authentication still applies to social logins.
+	if user.TOTPEnabled {
+		token, err := utils.GeneratePurposeToken(strconv.Itoa(int(user.Id)), utils.PurposeMFAPending, user.Email, mfaPendingTTL)
+		if err != nil {
+			log.Printf("Error generating MFA pending token for user %d: %v\n", user.Id, err)
+			fail("oidc_failed")
+			return
+		}
+		c.Redirect(302, frontendURL("/login#mfa_token="+token))
+		return
+	}
+
+	if err := startSession(c, *user); err != nil {
+		log.Printf("Error starting session for user %d: %v\n", user.Id, err)
+		fail("oidc_failed")
+		return
+	}
+
+	c.Redirect(302, frontendURL("/"))
+}
+
+// findOrLinkOIDCUser resolves the local user for an external identity. Known
+// identities map straight to their user; otherwise the identity is linked to
+// the user with the same (provider-verified) email, or a new user is created.
+func findOrLinkOIDCUser(provider string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	var user models.User
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+		if err == nil {
+			return tx.First(&user, identity.UserID).Error
+		}
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		email := strings.TrimSpace(claims.Email)
+		if email == "" || !claims.EmailVerified {
+			return errOIDCEmailUnverified
+		}
+
+		// Unscoped so a trashed account's address is not handed to a new account
+		err = tx.Unscoped().Where("email = ?", email).First(&user).Error
+		if err == nil && user.DeletedAt.Valid {
+			return errOIDCAccountDeleted
+		}
+		if err == gorm.ErrRecordNotFound {
+			firstName, lastName := claims.GivenName, claims.FamilyName
+			if firstName == "" && lastName == "" {
+				firstName = claims.Name
+			}
+			now := time.Now()
+			user = models.User{
+				FirstName:       firstName,
+				LastName:        lastName,
+				Email:           email,
+				Role:            models.RoleUser,
+				EmailVerifiedAt: &now,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else if !user.IsEmailVerified() {
+			// The provider has verified the address, so ours can be too.
+			if err := tx.Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.Id,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    email,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
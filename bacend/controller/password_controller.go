@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/platform/mailer"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// passwordResetTTL is how long an emailed reset link stays valid.
+const passwordResetTTL = time.Hour
+
+var errResetTokenInvalid = errors.New("reset token is invalid, expired or already used")
+
+// frontendURL builds an absolute link into the frontend from FRONTEND_URL.
+func frontendURL(path string) string {
+	base := os.Getenv("FRONTEND_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+// ForgotPasswordController emails a password reset link. It responds the same
+// way whether or not the email belongs to an account, so it cannot be used to
+// discover registered addresses.
+func ForgotPasswordController(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Email is required."})
+		return
+	}
+
+	response := gin.H{"message": "If an account exists for that email, a password reset link has been sent."}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", strings.TrimSpace(input.Email)).First(&user).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			log.Printf("Database error looking up user for password reset: %v\n", err)
+		}
+		c.JSON(200, response)
+		return
+	}
+
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		log.Printf("Error generating password reset token for user %d: %v\n", user.Id, err)
+		c.JSON(200, response)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent link is usable.
+		err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.Id).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.Id,
+			TokenHash: utils.HashToken(rawToken),
+			ExpiresAt: time.Now().Add(passwordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		log.Printf("Database error storing password reset token for user %d: %v\n", user.Id, err)
+		c.JSON(200, response)
+		return
+	}
+
+	// Send in the background so response time does not reveal whether the account exists.
+	go func(to string) {
+		err := mailer.Send(mailer.Message{
+			To:      to,
+			Subject: "Reset your Gin Blog password",
+			Body: fmt.Sprintf("We received a request to reset your password.\n\n"+
+				"Reset it here within the next hour:\n%s\n\n"+
+				"If you did not ask for this, you can ignore this email.",
+				frontendURL("/reset-password?token="+rawToken)),
+		})
+		if err != nil {
+			log.Printf("Error sending password reset email: %v\n", err)
+		}
+	}(user.Email)
+
+	c.JSON(200, response)
+}
+
+// ResetPasswordController sets a new password using a reset token. The token is
+// consumed, and every existing session of the user is revoked.
+func ResetPasswordController(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Token and new password are required."})
+		return
+	}
+
+	if !validatePassword(input.Password) {
+		c.JSON(400, gin.H{"message": "Password must be greater than 6 characters!"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var resetToken models.PasswordResetToken
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(input.Token), time.Now()).
+			First(&resetToken).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errResetTokenInvalid
+			}
+			return err
+		}
+
+		// Conditional update so two concurrent requests cannot both use the token.
+		result := tx.Model(&resetToken).Where("used_at IS NULL").Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenInvalid
+		}
+
+		var user models.User
+		if err := tx.First(&user, resetToken.UserID).Error; err != nil {
+			return err
+		}
+		if err := user.SetPassword(input.Password); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password", user.Password).Error; err != nil {
+			return err
+		}
+
+		return services.RevokeUserSessions(tx, user.Id, "")
+	})
+	if err != nil {
+		if errors.Is(err, errResetTokenInvalid) {
+			c.JSON(400, gin.H{"message": "This reset link is invalid or has expired. Please request a new one."})
+			return
+		}
+		log.Printf("Error resetting password: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to reset password due to server error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Password reset successfully! Please log in with your new password."})
+}
@@ -3,21 +3,37 @@ package controller
 import (
 	"Gin-Blog-Website/database"
 	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"fmt"
 	"log"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 func CreatePost(c *gin.Context) {
-	var blogpost models.Blog
+	if !requireVerifiedEmail(c) {
+		return
+	}
 
-	if err := c.ShouldBindJSON(&blogpost); err != nil {
-		log.Printf("Error binding payload for CreatePost: %v\n", err.Error())
-		c.JSON(400, gin.H{"message": "Invalid payload!"})
+	var input createPostInput
+	if !bindStrictJSON(c, &input) {
+		return
+	}
+	blogpost := models.Blog{
+		Title:       strings.TrimSpace(input.Title),
+		Description: input.Description,
+		Image:       strings.TrimSpace(input.Image),
+		PublishAt:   input.PublishAt,
+		CategoryID:  input.CategoryID,
+	}
+	if !checkPostCategory(c, blogpost.CategoryID) {
 		return
 	}
 
@@ -40,8 +56,12 @@ func CreatePost(c *gin.Context) {
 	// --- FIX END ---
 
 	blogpost.UserID = userID // Directly assign the uint userID
-	// NEW: Set IsApproved to false by default for pending approval
-	blogpost.IsApproved = false
+	// New posts go straight to the review queue unless saved as a draft
+	blogpost.Status = models.PostStatusSubmitted
+	if input.Draft {
+		blogpost.Status = models.PostStatusDraft
+	}
+	blogpost.Version = 1
 
 	var user models.User
 	// Use the uint userID directly for the database query
@@ -57,7 +77,35 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Create(&blogpost).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if input.Slug == "" {
+			slug, err := services.UniqueSlug(tx, blogpost.Title, 0)
+			if err != nil {
+				return err
+			}
+			blogpost.Slug = slug
+		} else if available, err := services.SlugAvailable(tx, input.Slug, 0); err != nil {
+			return err
+		} else if !available {
+			return services.ErrSlugTaken
+		} else {
+			blogpost.Slug = input.Slug
+		}
+
+		if err := tx.Create(&blogpost).Error; err != nil {
+			return err
+		}
+		if err := services.SetPostTags(tx, &blogpost, input.Tags); err != nil {
+			return err
+		}
+		_, err := services.RecordRevision(tx, blogpost, userID, nil)
+		return err
+	})
+	if errors.Is(err, services.ErrSlugTaken) {
+		c.JSON(409, gin.H{"message": "That slug is already used by another post."})
+		return
+	}
+	if err != nil {
 		log.Printf("Error creating blog post in database: %v\n", err.Error())
 		if strings.Contains(err.Error(), "foreign key constraint") {
 			c.JSON(400, gin.H{"message": "Invalid user associated with post (foreign key constraint violated)."})
@@ -67,36 +115,127 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	if input.Draft {
+		c.JSON(200, gin.H{"message": "Draft saved!", "post": blogpost})
+		return
+	}
 	c.JSON(200, gin.H{"message": "Post submitted for approval!", "post": blogpost})
 }
 
-func GetAllPost(c *gin.Context) {
+// Page sizes of the public post listings.
+const (
+	publicPostsPerPage = 5
+	maxPostsPerPage    = 50
+)
+
+// preloadPostDetails loads what readers see alongside a post.
+func preloadPostDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").Preload("Category").Preload("Tags")
+}
+
+// parseListingDate reads a date range bound given as an RFC 3339 timestamp or
+// a plain date. A plain date as the upper bound includes that whole day.
+func parseListingDate(value string, upper bool) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, true
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, false
+	}
+	if upper {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return &parsed, true
+}
+
+// listPublishedPosts responds with one page of the published posts selected
+// by filter. Clients can further filter by author (user ID), tag and a
+// publication date range (from, to), sort by newest, oldest, most_commented
+// or most_liked, and choose the page size with limit. Pages are addressed by
+// page number, or by the next_cursor of the previous page passed as cursor.
+// For the newest and oldest sorts the cursor stays fast however deep the
+// listing goes.
+func listPublishedPosts(c *gin.Context, filter func(db *gorm.DB) *gorm.DB) {
+	limit := publicPostsPerPage
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxPostsPerPage {
+			c.JSON(400, gin.H{"message": fmt.Sprintf("limit must be between 1 and %d.", maxPostsPerPage)})
+			return
+		}
+		limit = parsed
+	}
+
+	sort := c.DefaultQuery("sort", services.PostSortNewest)
+	if !services.IsValidPostSort(sort) {
+		c.JSON(400, gin.H{"message": "sort must be one of newest, oldest, most_commented or most_liked."})
+		return
+	}
+
+	filters := []func(db *gorm.DB) *gorm.DB{filter}
+	if raw := c.Query("author"); raw != "" {
+		authorID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"message": "author must be a user ID."})
+			return
+		}
+		filters = append(filters, services.PostAuthorFilter(uint(authorID)))
+	}
+	if tag := c.Query("tag"); tag != "" {
+		filters = append(filters, services.PostTagFilter(utils.Slugify(tag)))
+	}
+	from, okFrom := parseListingDate(c.Query("from"), false)
+	to, okTo := parseListingDate(c.Query("to"), true)
+	if !okFrom || !okTo {
+		c.JSON(400, gin.H{"message": "from and to must be dates (YYYY-MM-DD) or RFC 3339 timestamps."})
+		return
+	}
+	filters = append(filters, services.PostPublishedBetween(from, to))
+
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page < 1 {
 		page = 1
 	}
-	limit := 5
-	offset := (page - 1) * limit
-	var total int64
-	var getblog []models.Blog
-
-	// NEW: Only retrieve approved posts for public view
-	query := database.DB.Where("is_approved = ?", true).Preload("User")
-	query.Offset(offset).Limit(limit).Find(&getblog)
-	query.Model(&models.Blog{}).Count(&total) // Count only approved posts
-
-	lastPage := int(math.Ceil(float64(total) / float64(limit)))
+	cursor := c.Query("cursor")
+
+	result, err := services.ListPublishedPosts(services.PostListOptions{
+		Sort:   sort,
+		Limit:  limit,
+		Filter: services.CombinePostFilters(filters...),
+		Cursor: cursor,
+		Offset: (page - 1) * limit,
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		c.JSON(400, gin.H{"message": "Invalid cursor. Start again without one."})
+		return
+	}
+	if err != nil {
+		log.Printf("Database error listing published posts: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve posts."})
+		return
+	}
 
+	meta := gin.H{"limit": limit, "next_cursor": result.NextCursor}
+	if cursor == "" {
+		meta["total"] = result.Total
+		meta["page"] = page
+		meta["last_page"] = int(math.Ceil(float64(result.Total) / float64(limit)))
+	}
 	c.JSON(200, gin.H{
-		"data": getblog,
-		"meta": gin.H{
-			"total":     total,
-			"page":      page,
-			"last_page": lastPage,
-		},
+		"data": result.Posts,
+		"meta": meta,
 	})
 }
 
+// GetAllPost lists published posts; see listPublishedPosts for the query parameters.
+func GetAllPost(c *gin.Context) {
+	listPublishedPosts(c, nil)
+}
+
 func GetPostById(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -104,20 +243,61 @@ func GetPostById(c *gin.Context) {
 		return
 	}
 	var blogpost models.Blog
-	// NEW: For a single post, also check if it's approved for public viewing
-	result := database.DB.Where("id = ? AND is_approved = ?", id, true).Preload("User").First(&blogpost)
+	// For a single post, also check if it's published for public viewing
+	result := preloadPostDetails(database.DB).Where("id = ? AND status = ?", id, models.PostStatusPublished).First(&blogpost)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{"message": "Post not found or not yet approved."})
+			c.JSON(404, gin.H{"message": "Post not found or not yet published."})
 			return
 		}
 		log.Printf("Database error fetching post by ID %d: %v\n", id, result.Error)
 		c.JSON(500, gin.H{"message": "Database error retrieving post."})
 		return
 	}
+	setPostETag(c, blogpost)
 	c.JSON(200, gin.H{"data": blogpost})
 }
 
+// GetPostBySlug returns a published post by its slug. Old slugs of a published
+// post answer with a 302 redirect to its current one. The redirect is not
+// permanent because authors can switch back to an earlier slug, and a cached
+// 301 would then loop.
+func GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var blogpost models.Blog
+	result := preloadPostDetails(database.DB).Where("slug = ? AND status = ?", slug, models.PostStatusPublished).First(&blogpost)
+	if result.Error == nil {
+		setPostETag(c, blogpost)
+		c.JSON(200, gin.H{"data": blogpost})
+		return
+	}
+	if result.Error != gorm.ErrRecordNotFound {
+		log.Printf("Database error fetching post by slug %q: %v\n", slug, result.Error)
+		c.JSON(500, gin.H{"message": "Database error retrieving post."})
+		return
+	}
+
+	// Not a current slug; follow the history to the post's current slug
+	postIDs := database.DB.Model(&models.PostSlug{}).Select("blog_id").Where("slug = ?", slug)
+	result = database.DB.Select("slug").Where("id IN (?) AND status = ?", postIDs, models.PostStatusPublished).First(&blogpost)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Post not found or not yet published."})
+			return
+		}
+		log.Printf("Database error following old slug %q: %v\n", slug, result.Error)
+		c.JSON(500, gin.H{"message": "Database error retrieving post."})
+		return
+	}
+	c.Redirect(302, "/api/posts/by-slug/"+blogpost.Slug)
+}
+
+// UpdatePostById edits one of the authenticated user's posts. Every change to
+// the title, description or image is kept as a revision. Approved and
+// published posts whose content changes go back to the review queue and stay
+// hidden until they are approved again. The If-Match header must carry the
+// post's current ETag, as sent by GetPostById or found in its version field.
 func UpdatePostById(c *gin.Context) {
 	// 1. Get post ID from URL parameter
 	postIDStr := c.Param("id")
@@ -165,30 +345,61 @@ func UpdatePostById(c *gin.Context) {
 		c.JSON(403, gin.H{"message": "You are not authorized to update this post."})
 		return
 	}
-
-	// 5. Bind the incoming JSON payload for updates
-	var updates map[string]interface{}
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		log.Printf("Error binding update payload for Post ID %d: %v\n", postID, err.Error())
-		c.JSON(400, gin.H{"message": "Invalid update payload!"})
+	if !checkPostIfMatch(c, existingPost) {
 		return
 	}
 
-	// 6. Update the post in the database
-	updateResult := database.DB.Model(&existingPost).Updates(updates)
-	if updateResult.Error != nil {
-		log.Printf("Error updating post %d in database: %v\n", postID, updateResult.Error)
-		c.JSON(500, gin.H{"message": "Failed to update post due to database error."})
+	// 5. Bind the incoming JSON payload for updates. Only content can be edited
+	// here; status changes go through the lifecycle endpoints.
+	var input updatePostInput
+	if !bindStrictJSON(c, &input) {
+		return
+	}
+	updates := input.updates()
+	if len(updates) == 0 && input.Tags == nil {
+		c.JSON(400, gin.H{"message": "Nothing to update."})
+		return
+	}
+	if input.CategoryID.Set && !checkPostCategory(c, input.CategoryID.Value) {
 		return
 	}
 
-	if updateResult.RowsAffected == 0 {
-		log.Printf("No changes applied when updating post %d. Possibly same data sent.", postID)
-		c.JSON(200, gin.H{"message": "Post updated, but no new changes were applied (possibly same data)."})
+	// 6. Update the post in the database, recording a revision if its content changed
+	var resubmitted bool
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		resubmitted, err = services.ApplyPostEdit(tx, &existingPost, updates, currentUserID)
+		if err != nil || input.Tags == nil {
+			return err
+		}
+		return services.SetPostTags(tx, &existingPost, *input.Tags)
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrPostModified) {
+			respondPostModified(c, existingPost)
+			return
+		}
+		if errors.Is(err, services.ErrSlugTaken) {
+			c.JSON(409, gin.H{"message": "That slug is already used by another post."})
+			return
+		}
+		log.Printf("Error updating post %d in database: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Failed to update post due to database error."})
 		return
 	}
 
 	// 7. Respond with success
+	var updatedPost models.Blog
+	if err := preloadPostDetails(database.DB).First(&updatedPost, existingPost.ID).Error; err != nil {
+		// The update went through; answer with the copy we already have
+		log.Printf("Error reloading post %d after update: %v\n", existingPost.ID, err)
+	} else {
+		existingPost = updatedPost
+	}
+	setPostETag(c, existingPost)
+	if resubmitted {
+		c.JSON(200, gin.H{"message": "Post updated and sent back for approval.", "post": existingPost})
+		return
+	}
 	c.JSON(200, gin.H{"message": "Post updated successfully!", "post": existingPost})
 }
 
@@ -211,7 +422,12 @@ func GetMyPosts(c *gin.Context) { // Renamed from UniquePost for clarity
 
 	var blogs []models.Blog
 	// Use the uint currentUserID directly for the database query
-	result := database.DB.Where("user_id = ?", currentUserID).Preload("User").Find(&blogs)
+	query := database.DB.Where("user_id = ?", currentUserID)
+	// Optionally narrow down to one status, e.g. ?status=draft
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	result := preloadPostDetails(query).Order("updated_at desc").Find(&blogs)
 	if result.Error != nil {
 		log.Printf("Error retrieving posts for user %d: %v\n", currentUserID, result.Error)
 		c.JSON(500, gin.H{"message": "Could not retrieve your posts."})
@@ -273,18 +489,12 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	// 4. Proceed with deletion
-	deleteResult := database.DB.Delete(&blog) // Delete the retrieved blog object
-	if deleteResult.Error != nil {
-		log.Printf("Error deleting post %d from database: %v\n", postID, deleteResult.Error)
+	// 4. Move the post and its comments to the trash; it can be restored until purged
+	if err := services.TrashPost(database.DB, blog); err != nil {
+		log.Printf("Error deleting post %d from database: %v\n", postID, err)
 		c.JSON(500, gin.H{"message": "Failed to delete the post due to a database error."})
 		return
 	}
 
-	if deleteResult.RowsAffected == 0 {
-		c.JSON(404, gin.H{"message": "Post not found or already deleted."})
-		return
-	}
-
-	c.JSON(200, gin.H{"message": "Post deleted successfully!"})
+	c.JSON(200, gin.H{"message": "Post moved to the trash. You can restore it from there."})
 }
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits on post content.
+const (
+	minPostTitleLength       = 3
+	maxPostTitleLength       = 200
+	maxPostDescriptionLength = 100_000
+	maxPostImageURLLength    = 2048
+	maxTagLength             = 50
+	// maxPostPayloadBytes caps the request body of CreatePost and UpdatePostById.
+	maxPostPayloadBytes = 1 << 20
+)
+
+// createPostInput is the body of CreatePost. Everything else about a new post,
+// such as its author and status, is decided by the server.
+type createPostInput struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Image       string     `json:"image"`
+	PublishAt   *time.Time `json:"publish_at"`
+	// Slug is optional; by default one is derived from the title.
+	Slug       string   `json:"slug"`
+	CategoryID *uint    `json:"category_id"`
+	Tags       []string `json:"tags"`
+	// Draft saves the post without submitting it for review.
+	Draft bool `json:"draft"`
+}
+
+// updatePostInput is the body of UpdatePostById. Fields left out are not changed.
+type updatePostInput struct {
+	Title       *string             `json:"title"`
+	Description *string             `json:"description"`
+	Image       *string             `json:"image"`
+	PublishAt   nullable[time.Time] `json:"publish_at"`
+	Slug        *string             `json:"slug"`
+	CategoryID  nullable[uint]      `json:"category_id"`
+	// Tags replaces all of the post's tags; an empty list removes them.
+	Tags *[]string `json:"tags"`
+}
+
+// nullable is a JSON field that tells an explicit null, which clears the
+// value, apart from a field that was left out.
+type nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
+
+// validate checks the fields of a new post and returns a message per invalid field.
+func (input createPostInput) validate() map[string]string {
+	problems := map[string]string{}
+	validatePostTitle(problems, input.Title)
+	validatePostDescription(problems, input.Description)
+	validatePostImage(problems, input.Image)
+	if input.Slug != "" {
+		validatePostSlug(problems, input.Slug)
+	}
+	validatePostTags(problems, input.Tags)
+	return problems
+}
+
+// validate checks the fields that are being changed and returns a message per invalid field.
+func (input updatePostInput) validate() map[string]string {
+	problems := map[string]string{}
+	if input.Title != nil {
+		validatePostTitle(problems, *input.Title)
+	}
+	if input.Description != nil {
+		validatePostDescription(problems, *input.Description)
+	}
+	if input.Image != nil {
+		validatePostImage(problems, *input.Image)
+	}
+	if input.Slug != nil {
+		validatePostSlug(problems, *input.Slug)
+	}
+	if input.Tags != nil {
+		validatePostTags(problems, *input.Tags)
+	}
+	return problems
+}
+
+// updates returns the columns to change, keyed by column name.
+func (input updatePostInput) updates() map[string]interface{} {
+	updates := map[string]interface{}{}
+	if input.Title != nil {
+		updates["title"] = strings.TrimSpace(*input.Title)
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.Image != nil {
+		updates["image"] = strings.TrimSpace(*input.Image)
+	}
+	if input.PublishAt.Set {
+		updates["publish_at"] = input.PublishAt.Value
+	}
+	if input.CategoryID.Set {
+		updates["category_id"] = input.CategoryID.Value
+	}
+	if input.Slug != nil {
+		updates["slug"] = *input.Slug
+	}
+	return updates
+}
+
+func validatePostTitle(problems map[string]string, title string) {
+	length := utf8.RuneCountInString(strings.TrimSpace(title))
+	if length < minPostTitleLength || length > maxPostTitleLength {
+		problems["title"] = fmt.Sprintf("Title must be between %d and %d characters.", minPostTitleLength, maxPostTitleLength)
+	}
+}
+
+func validatePostDescription(problems map[string]string, description string) {
+	if strings.TrimSpace(description) == "" {
+		problems["description"] = "Description cannot be empty."
+	} else if utf8.RuneCountInString(description) > maxPostDescriptionLength {
+		problems["description"] = fmt.Sprintf("Description must be at most %d characters.", maxPostDescriptionLength)
+	}
+}
+
+// validatePostImage accepts no image, or an absolute http(s) URL such as the
+// ones returned by Upload.
+func validatePostImage(problems map[string]string, image string) {
+	image = strings.TrimSpace(image)
+	if image == "" {
+		return
+	}
+	parsed, err := url.Parse(image)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(image) > maxPostImageURLLength {
+		problems["image"] = "Image must be an http or https URL."
+	}
+}
+
+func validatePostSlug(problems map[string]string, slug string) {
+	if !utils.ValidSlug(slug) {
+		problems["slug"] = fmt.Sprintf("Slug must be at most %d lowercase letters, digits and single hyphens.", utils.MaxSlugLength)
+	}
+}
+
+// validatePostTags checks tag names as they will be stored, see services.NormalizeTags.
+func validatePostTags(problems map[string]string, tags []string) {
+	for _, tag := range tags {
+		name := utils.Slugify(tag)
+		if name == "" || len(name) > maxTagLength {
+			problems["tags"] = fmt.Sprintf("Each tag must contain letters or digits and be at most %d characters long.", maxTagLength)
+			return
+		}
+	}
+	if len(services.NormalizeTags(tags)) > models.MaxPostTags {
+		problems["tags"] = fmt.Sprintf("A post can have at most %d tags.", models.MaxPostTags)
+	}
+}
+
+// bindStrictJSON decodes the request body into input, rejecting unknown
+// fields, values of the wrong type and oversized bodies, and validates it.
+// It responds with 400 itself and returns false when the request was rejected.
+func bindStrictJSON[T interface{ validate() map[string]string }](c *gin.Context, input *T) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxPostPayloadBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(input); err != nil {
+		c.JSON(400, gin.H{"message": jsonDecodeMessage(err)})
+		return false
+	}
+	if decoder.More() {
+		c.JSON(400, gin.H{"message": "Request body must be a single JSON object."})
+		return false
+	}
+
+	if problems := (*input).validate(); len(problems) > 0 {
+		c.JSON(400, gin.H{"message": "Invalid payload!", "errors": problems})
+		return false
+	}
+	return true
+}
+
+// jsonDecodeMessage describes a JSON decoding error for the client.
+func jsonDecodeMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "Request body is required."
+	case errors.As(err, &sizeErr):
+		return "Request body is too large."
+	case errors.As(err, &typeErr):
+		return "Field '" + typeErr.Field + "' has the wrong type."
+	case errors.As(err, &timeErr):
+		return "publish_at must be an RFC 3339 timestamp or null."
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return "Field '" + field + "' cannot be set."
+	default:
+		return "Invalid payload!"
+	}
+}
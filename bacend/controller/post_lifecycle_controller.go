@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bindReviewNote reads the optional {"note": "..."} body of a lifecycle action.
+// An empty body is allowed. It returns false when the request was rejected.
+func bindReviewNote(c *gin.Context) (string, bool) {
+	var input struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{"message": "Invalid request body."})
+		return "", false
+	}
+	return input.Note, true
+}
+
+// setPostETag sends the post's version as the ETag of the response.
+func setPostETag(c *gin.Context, post models.Blog) {
+	c.Header("ETag", utils.VersionETag(post.Version))
+}
+
+// checkPostIfMatch requires an If-Match header with the post's current ETag,
+// so a change based on a stale copy cannot overwrite someone else's. It
+// responds with 428 when the header is missing and with 412 and the current
+// post when it does not match. It returns false when the request was rejected.
+func checkPostIfMatch(c *gin.Context, post models.Blog) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(428, gin.H{"message": "This request needs an If-Match header with the post's ETag."})
+		return false
+	}
+	if !utils.IfMatches(header, utils.VersionETag(post.Version)) {
+		respondPostModified(c, post)
+		return false
+	}
+	return true
+}
+
+// respondPostModified responds with 412 and the current copy of a post that
+// was changed by someone else.
+func respondPostModified(c *gin.Context, post models.Blog) {
+	var current models.Blog
+	if err := database.DB.First(&current, post.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Post not found."})
+			return
+		}
+		log.Printf("Database error reloading post %d: %v\n", post.ID, err)
+		c.JSON(500, gin.H{"message": "Database error retrieving post."})
+		return
+	}
+
+	setPostETag(c, current)
+	c.JSON(412, gin.H{"message": "The post was changed by someone else. Review the current version and try again.", "post": current})
+}
+
+// respondTransitionError maps services.TransitionPost errors to responses.
+func respondTransitionError(c *gin.Context, err error, post models.Blog, status string) {
+	if errors.Is(err, services.ErrPostModified) {
+		respondPostModified(c, post)
+		return
+	}
+	if errors.Is(err, services.ErrIllegalTransition) {
+		c.JSON(409, gin.H{
+			"message": "A " + post.Status + " post cannot be moved to " + status + ".",
+			"status":  post.Status,
+			"allowed": models.PostTransitions[post.Status],
+		})
+		return
+	}
+	log.Printf("Database error moving post %d to %s: %v\n", post.ID, status, err)
+	c.JSON(500, gin.H{"message": "Failed to update post status due to database error."})
+}
+
+// loadPostForTransition loads the post named by the :id parameter, responding
+// with 400/404/500 itself. It returns false when the request was rejected.
+func loadPostForTransition(c *gin.Context, post *models.Blog) bool {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid post ID format."})
+		return false
+	}
+	if err := database.DB.First(post, postID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Post not found."})
+			return false
+		}
+		log.Printf("Database error finding post %d: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Database error retrieving post."})
+		return false
+	}
+	return true
+}
+
+// transitionMyPost moves one of the authenticated user's own posts to status.
+// The If-Match header must carry the post's ETag.
+func transitionMyPost(c *gin.Context, status string, message string) {
+	userID := c.MustGet("userID").(uint)
+
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+	if post.UserID != userID {
+		c.JSON(403, gin.H{"message": "You are not authorized to change this post."})
+		return
+	}
+	if !checkPostIfMatch(c, post) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.TransitionPost(tx, &post, status, userID, "", false)
+	})
+	if err != nil {
+		respondTransitionError(c, err, post, status)
+		return
+	}
+
+	setPostETag(c, post)
+	c.JSON(200, gin.H{"message": message, "post": post})
+}
+
+// SubmitMyPost sends a draft, or a post with changes requested, to the review queue.
+func SubmitMyPost(c *gin.Context) {
+	transitionMyPost(c, models.PostStatusSubmitted, "Post submitted for approval!")
+}
+
+// WithdrawMyPost takes a post out of review, or out of the archive, back to draft.
+func WithdrawMyPost(c *gin.Context) {
+	transitionMyPost(c, models.PostStatusDraft, "Post moved back to drafts.")
+}
+
+// ArchiveMyPost unpublishes one of the authenticated user's published posts.
+func ArchiveMyPost(c *gin.Context) {
+	transitionMyPost(c, models.PostStatusArchived, "Post archived.")
+}
+
+// postHistory returns the status changes of a post, oldest first.
+func postHistory(c *gin.Context, postID uint) {
+	var changes []models.PostStatusChange
+	err := database.DB.Where("blog_id = ?", postID).Order("created_at asc, id asc").
+		Preload("Actor", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "first_name", "last_name", "role")
+		}).Find(&changes).Error
+	if err != nil {
+		log.Printf("Database error retrieving history of post %d: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve post history."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": changes})
+}
+
+// GetMyPostHistory lists the status changes and reviewer notes of one of the
+// authenticated user's posts.
+func GetMyPostHistory(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+	if post.UserID != userID {
+		c.JSON(403, gin.H{"message": "You are not authorized to view this post's history."})
+		return
+	}
+
+	postHistory(c, post.ID)
+}
+
+// GetPostHistoryForAdmin lists the status changes and reviewer notes of any post.
+// Requires the posts.approve permission.
+func GetPostHistoryForAdmin(c *gin.Context) {
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+
+	postHistory(c, post.ID)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// findPublishedPostID parses the :id parameter and checks that it names a
+// published post, responding with 400/404/500 itself. It returns false when
+// the request was rejected.
+func findPublishedPostID(c *gin.Context) (uint, bool) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid post ID format."})
+		return 0, false
+	}
+	var post models.Blog
+	if err := database.DB.Select("id").Where("id = ? AND status = ?", postID, models.PostStatusPublished).First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Post not found or not yet published."})
+			return 0, false
+		}
+		log.Printf("Database error finding post %d: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Database error retrieving post."})
+		return 0, false
+	}
+	return post.ID, true
+}
+
+// respondLikes responds with the like count of a post and whether the user likes it.
+func respondLikes(c *gin.Context, postID uint, liked bool, message string) {
+	var likes int64
+	if err := database.DB.Model(&models.PostLike{}).Where("blog_id = ?", postID).Count(&likes).Error; err != nil {
+		log.Printf("Database error counting likes of post %d: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Database error counting likes."})
+		return
+	}
+	c.JSON(200, gin.H{"message": message, "liked": liked, "like_count": likes})
+}
+
+// LikePost likes a published post as the authenticated user. Liking a post
+// twice has no further effect.
+func LikePost(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	postID, ok := findPublishedPostID(c)
+	if !ok {
+		return
+	}
+
+	like := models.PostLike{BlogID: postID, UserID: userID}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&like).Error; err != nil {
+		log.Printf("Database error liking post %d for user %d: %v\n", postID, userID, err)
+		c.JSON(500, gin.H{"message": "Failed to like post due to database error."})
+		return
+	}
+
+	respondLikes(c, postID, true, "Post liked!")
+}
+
+// UnlikePost takes back the authenticated user's like of a post.
+func UnlikePost(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	postID, ok := findPublishedPostID(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Where("blog_id = ? AND user_id = ?", postID, userID).Delete(&models.PostLike{}).Error; err != nil {
+		log.Printf("Database error unliking post %d for user %d: %v\n", postID, userID, err)
+		c.JSON(500, gin.H{"message": "Failed to unlike post due to database error."})
+		return
+	}
+
+	respondLikes(c, postID, false, "Like removed.")
+}
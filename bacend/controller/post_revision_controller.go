@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// preloadRevisionEditor loads the public fields of a revision's editor, also
+// when their account is in the trash.
+func preloadRevisionEditor(db *gorm.DB) *gorm.DB {
+	return db.Preload("Editor", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "first_name", "last_name", "role")
+	})
+}
+
+// loadMyPostForRevisions loads the post named by the :id parameter and checks
+// that the authenticated user wrote it. It returns false when the request was rejected.
+func loadMyPostForRevisions(c *gin.Context, post *models.Blog) bool {
+	if !loadPostForTransition(c, post) {
+		return false
+	}
+	if post.UserID != c.MustGet("userID").(uint) {
+		c.JSON(403, gin.H{"message": "You are not authorized to view this post's revisions."})
+		return false
+	}
+	return true
+}
+
+// findRevision loads revision number of postID, responding with 400/404/500
+// itself. It returns false when the request was rejected.
+func findRevision(c *gin.Context, postID uint, number string, revision *models.PostRevision) bool {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		c.JSON(400, gin.H{"message": "Invalid revision number."})
+		return false
+	}
+	err = preloadRevisionEditor(database.DB).Where("blog_id = ? AND number = ?", postID, n).First(revision).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Revision " + number + " not found."})
+			return false
+		}
+		log.Printf("Database error finding revision %d of post %d: %v\n", n, postID, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve revision."})
+		return false
+	}
+	return true
+}
+
+// postRevisions lists the revisions of a post, newest first, without their content.
+func postRevisions(c *gin.Context, postID uint) {
+	var revisions []models.PostRevision
+	err := preloadRevisionEditor(database.DB).Omit("description").
+		Where("blog_id = ?", postID).Order("number desc").Find(&revisions).Error
+	if err != nil {
+		log.Printf("Database error retrieving revisions of post %d: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve post revisions."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": revisions})
+}
+
+// postDiff responds with a line-level diff of the title and description of two
+// revisions of a post, given as the from and to query parameters. to defaults
+// to the latest revision.
+func postDiff(c *gin.Context, postID uint) {
+	var from, to models.PostRevision
+	if !findRevision(c, postID, c.Query("from"), &from) {
+		return
+	}
+	if toNumber := c.Query("to"); toNumber != "" {
+		if !findRevision(c, postID, toNumber, &to) {
+			return
+		}
+	} else if err := preloadRevisionEditor(database.DB).Where("blog_id = ?", postID).Order("number desc").First(&to).Error; err != nil {
+		log.Printf("Database error finding latest revision of post %d: %v\n", postID, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve revision."})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"from":        from,
+		"to":          to,
+		"title":       utils.DiffLines(from.Title, to.Title),
+		"description": utils.DiffLines(from.Description, to.Description),
+		"image":       gin.H{"from": from.Image, "to": to.Image, "changed": from.Image != to.Image},
+	})
+}
+
+// GetMyPostRevisions lists the revisions of one of the authenticated user's posts.
+func GetMyPostRevisions(c *gin.Context) {
+	var post models.Blog
+	if !loadMyPostForRevisions(c, &post) {
+		return
+	}
+
+	postRevisions(c, post.ID)
+}
+
+// GetMyPostRevision returns one revision, with its full content, of one of the
+// authenticated user's posts.
+func GetMyPostRevision(c *gin.Context) {
+	var post models.Blog
+	if !loadMyPostForRevisions(c, &post) {
+		return
+	}
+
+	var revision models.PostRevision
+	if !findRevision(c, post.ID, c.Param("rev"), &revision) {
+		return
+	}
+
+	c.JSON(200, gin.H{"data": revision})
+}
+
+// GetMyPostDiff compares two revisions of one of the authenticated user's posts.
+func GetMyPostDiff(c *gin.Context) {
+	var post models.Blog
+	if !loadMyPostForRevisions(c, &post) {
+		return
+	}
+
+	postDiff(c, post.ID)
+}
+
+// RestoreMyPostRevision rolls one of the authenticated user's posts back to an
+// older revision. The rollback is itself stored as a new revision, and like any
+// other edit it sends an approved or published post back for approval.
+func RestoreMyPostRevision(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+	if post.UserID != userID {
+		c.JSON(403, gin.H{"message": "You are not authorized to update this post."})
+		return
+	}
+
+	if !checkPostIfMatch(c, post) {
+		return
+	}
+
+	var revision models.PostRevision
+	if !findRevision(c, post.ID, c.Param("rev"), &revision) {
+		return
+	}
+
+	var resubmitted bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		resubmitted, err = services.RestoreRevision(tx, &post, revision, userID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRevisionUnchanged):
+			c.JSON(400, gin.H{"message": "The post already has the content of this revision."})
+		case errors.Is(err, services.ErrPostModified):
+			respondPostModified(c, post)
+		default:
+			log.Printf("Database error restoring revision %d of post %d: %v\n", revision.Number, post.ID, err)
+			c.JSON(500, gin.H{"message": "Failed to restore revision due to database error."})
+		}
+		return
+	}
+
+	setPostETag(c, post)
+	if resubmitted {
+		c.JSON(200, gin.H{"message": "Revision restored and post sent back for approval.", "post": post})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Revision restored successfully!", "post": post})
+}
+
+// GetPostRevisionsForAdmin lists the revisions of any post.
+// Requires the posts.approve permission.
+func GetPostRevisionsForAdmin(c *gin.Context) {
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+
+	postRevisions(c, post.ID)
+}
+
+// GetPostRevisionForAdmin returns one revision, with its full content, of any post.
+// Requires the posts.approve permission.
+func GetPostRevisionForAdmin(c *gin.Context) {
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+
+	var revision models.PostRevision
+	if !findRevision(c, post.ID, c.Param("rev"), &revision) {
+		return
+	}
+
+	c.JSON(200, gin.H{"data": revision})
+}
+
+// GetPostDiffForAdmin compares two revisions of any post, e.g. what changed
+// since it was last approved.
+// Requires the posts.approve permission.
+func GetPostDiffForAdmin(c *gin.Context) {
+	var post models.Blog
+	if !loadPostForTransition(c, &post) {
+		return
+	}
+
+	postDiff(c, post.ID)
+}
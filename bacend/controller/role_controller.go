@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// loadPermissions resolves permission names to records, rejecting unknown names.
+func loadPermissions(names []string) ([]models.Permission, string) {
+	for _, name := range names {
+		if !models.IsValidPermission(name) {
+			return nil, "Unknown permission '" + name + "'. Allowed permissions: " + strings.Join(models.AllPermissions, ", ") + "."
+		}
+	}
+
+	permissions := []models.Permission{}
+	if len(names) == 0 {
+		return permissions, ""
+	}
+	if err := database.DB.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		log.Printf("Admin: Database error loading permissions: %v\n", err)
+		return nil, "Failed to load permissions."
+	}
+	return permissions, ""
+}
+
+// checkPermissionsHeld rejects with 403 a change that would hand out any of
+// wanted when the caller's own role does not grant it, so roles.manage cannot
+// be used to gain more permissions. It returns false when the request was rejected.
+func checkPermissionsHeld(c *gin.Context, caller models.User, wanted []string) bool {
+	missing, err := services.PermissionsNotHeld(caller, wanted)
+	if err != nil {
+		log.Printf("Admin: Database error checking permissions of user %d: %v\n", caller.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to check permissions."})
+		return false
+	}
+	if len(missing) > 0 {
+		c.JSON(403, gin.H{"message": "You cannot grant permissions you do not have yourself.", "missing": missing})
+		return false
+	}
+	return true
+}
+
+// checkRoleWithinCaller rejects with 403 an action on a role, or on a user
+// holding it, when the role grants permissions the caller's own role does
+// not. It returns false when the request was rejected.
+func checkRoleWithinCaller(c *gin.Context, caller models.User, roleName string) bool {
+	missing, err := services.MissingPermissions(caller, roleName)
+	if err != nil {
+		log.Printf("Admin: Database error comparing permissions of user %d and role '%s': %v\n", caller.Id, roleName, err)
+		c.JSON(500, gin.H{"message": "Failed to check permissions."})
+		return false
+	}
+	if len(missing) > 0 {
+		c.JSON(403, gin.H{
+			"message": "The role '" + roleName + "' grants permissions you do not have.",
+			"missing": missing,
+		})
+		return false
+	}
+	return true
+}
+
+// GetPermissionsForAdmin lists every permission that can be granted to a role.
+// Requires the roles.manage permission.
+func GetPermissionsForAdmin(c *gin.Context) {
+	c.JSON(200, gin.H{"data": models.AllPermissions})
+}
+
+// GetRolesForAdmin lists all roles with their permissions.
+// Requires the roles.manage permission.
+func GetRolesForAdmin(c *gin.Context) {
+	var roles []models.Role
+	if err := database.DB.Preload("Permissions").Order("name asc").Find(&roles).Error; err != nil {
+		log.Printf("Admin: Database error retrieving roles: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve roles."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": roles})
+}
+
+// CreateRoleAsAdmin creates a new role with the given permissions, which the
+// caller must hold themselves.
+// Requires the roles.manage permission.
+func CreateRoleAsAdmin(c *gin.Context) {
+	caller := c.MustGet("user").(models.User)
+	var data struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(400, gin.H{"message": "Invalid data provided. Role name is required."})
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSpace(data.Name))
+	if !roleNamePattern.MatchString(name) {
+		c.JSON(400, gin.H{"message": "Invalid role name. Use 2-50 lowercase letters, digits, '-' or '_', starting with a letter."})
+		return
+	}
+
+	permissions, errMessage := loadPermissions(data.Permissions)
+	if errMessage != "" {
+		c.JSON(400, gin.H{"message": errMessage})
+		return
+	}
+	if !checkPermissionsHeld(c, caller, data.Permissions) {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.Role{}).Where("name = ?", name).Count(&count)
+	if count > 0 {
+		c.JSON(400, gin.H{"message": "A role with this name already exists."})
+		return
+	}
+
+	role := models.Role{Name: name, Description: data.Description, Permissions: permissions}
+	if err := database.DB.Create(&role).Error; err != nil {
+		log.Printf("Admin: Database error creating role '%s': %v\n", name, err)
+		c.JSON(500, gin.H{"message": "Failed to create role due to database error."})
+		return
+	}
+
+	c.JSON(201, gin.H{"message": "Role created successfully!", "role": role})
+}
+
+// UpdateRoleAsAdmin replaces a role's description and permissions.
+// The built-in admin role always holds every permission and cannot be edited.
+// Callers cannot edit their own role, roles with permissions they lack, or
+// grant permissions they lack.
+// Requires the roles.manage permission.
+func UpdateRoleAsAdmin(c *gin.Context) {
+	caller := c.MustGet("user").(models.User)
+	name := c.Param("name")
+	if name == models.RoleAdmin {
+		c.JSON(400, gin.H{"message": "The admin role always holds every permission and cannot be edited."})
+		return
+	}
+	if name == caller.Role {
+		c.JSON(403, gin.H{"message": "You cannot edit your own role."})
+		return
+	}
+
+	var data struct {
+		Description *string  `json:"description"`
+		Permissions []string `json:"permissions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(400, gin.H{"message": "Invalid data provided. Permissions are required."})
+		return
+	}
+
+	permissions, errMessage := loadPermissions(data.Permissions)
+	if errMessage != "" {
+		c.JSON(400, gin.H{"message": errMessage})
+		return
+	}
+
+	var role models.Role
+	if err := database.DB.Where("name = ?", name).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Role not found."})
+			return
+		}
+		log.Printf("Admin: Database error finding role '%s': %v\n", name, err)
+		c.JSON(500, gin.H{"message": "Failed to update role."})
+		return
+	}
+	if !checkRoleWithinCaller(c, caller, role.Name) || !checkPermissionsHeld(c, caller, data.Permissions) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if data.Description != nil {
+			if err := tx.Model(&role).Update("description", *data.Description).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&role).Association("Permissions").Replace(permissions)
+	})
+	if err != nil {
+		log.Printf("Admin: Database error updating role '%s': %v\n", name, err)
+		c.JSON(500, gin.H{"message": "Failed to update role due to database error."})
+		return
+	}
+
+	database.DB.Preload("Permissions").First(&role, role.ID)
+	c.JSON(200, gin.H{"message": "Role updated successfully!", "role": role})
+}
+
+// DeleteRoleAsAdmin deletes a custom role that no user is assigned to.
+// Requires the roles.manage permission.
+func DeleteRoleAsAdmin(c *gin.Context) {
+	name := c.Param("name")
+
+	var role models.Role
+	if err := database.DB.Where("name = ?", name).First(&role).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Role not found."})
+			return
+		}
+		log.Printf("Admin: Database error finding role '%s' for deletion: %v\n", name, err)
+		c.JSON(500, gin.H{"message": "Failed to delete role."})
+		return
+	}
+
+	if role.BuiltIn {
+		c.JSON(400, gin.H{"message": "Built-in roles cannot be deleted."})
+		return
+	}
+
+	var assigned int64
+	database.DB.Model(&models.User{}).Where("role = ?", role.Name).Count(&assigned)
+	if assigned > 0 {
+		c.JSON(400, gin.H{"message": "This role is still assigned to users. Reassign them first."})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		log.Printf("Admin: Database error deleting role '%s': %v\n", name, err)
+		c.JSON(500, gin.H{"message": "Failed to delete role due to database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Role deleted successfully!"})
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"Gin-Blog-Website/services"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// searchResultsPerPage is the page size of SearchPosts.
+	searchResultsPerPage = 10
+	// maxSearchQueryLength caps the length of the q parameter.
+	maxSearchQueryLength = 200
+)
+
+// SearchPosts searches the title and description of published posts. q takes
+// words, "quoted phrases", OR and -excluded words. Results are ranked, with
+// title matches counting more, and carry HTML snippets in which the matching
+// words are wrapped in <mark> tags.
+func SearchPosts(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(400, gin.H{"message": "Search query 'q' is required."})
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		c.JSON(400, gin.H{"message": "Search query is too long."})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit := searchResultsPerPage
+	offset := (page - 1) * limit
+
+	hits, total, err := services.SearchPublishedPosts(query, limit, offset)
+	if err != nil {
+		log.Printf("Database error searching posts for %q: %v\n", query, err)
+		c.JSON(500, gin.H{"message": "Search failed due to database error."})
+		return
+	}
+
+	lastPage := int(math.Ceil(float64(total) / float64(limit)))
+
+	c.JSON(200, gin.H{
+		"data": hits,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"last_page": lastPage,
+		},
+	})
+}
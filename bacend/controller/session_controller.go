@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetMySessions lists the authenticated user's active sessions (devices).
+func GetMySessions(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	currentSessionID := c.GetString("sessionID")
+
+	sessions, err := services.ListActiveSessions(userID)
+	if err != nil {
+		log.Printf("Database error listing sessions for user %d: %v\n", userID, err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve your sessions."})
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].JTI == currentSessionID
+	}
+
+	c.JSON(200, gin.H{"data": sessions})
+}
+
+// RevokeMySession logs out one of the authenticated user's devices.
+func RevokeMySession(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid session ID format."})
+		return
+	}
+
+	var session models.Session
+	if err := database.DB.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"message": "Session not found."})
+			return
+		}
+		log.Printf("Database error finding session %d for user %d: %v\n", sessionID, userID, err)
+		c.JSON(500, gin.H{"message": "Failed to revoke session."})
+		return
+	}
+
+	if err := services.RevokeSession(database.DB, session.JTI); err != nil {
+		log.Printf("Database error revoking session %d for user %d: %v\n", sessionID, userID, err)
+		c.JSON(500, gin.H{"message": "Failed to revoke session."})
+		return
+	}
+
+	if session.JTI == c.GetString("sessionID") {
+		clearAuthCookies(c)
+	}
+
+	c.JSON(200, gin.H{"message": "Session revoked successfully!"})
+}
+
+// RevokeAllMySessions logs the authenticated user out everywhere.
+// Pass ?keep_current=true to keep the session making the request.
+func RevokeAllMySessions(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	keepCurrent := c.Query("keep_current") == "true"
+
+	exceptSessionID := ""
+	if keepCurrent {
+		exceptSessionID = c.GetString("sessionID")
+	}
+
+	if err := services.RevokeUserSessions(database.DB, userID, exceptSessionID); err != nil {
+		log.Printf("Database error revoking all sessions for user %d: %v\n", userID, err)
+		c.JSON(500, gin.H{"message": "Failed to log out of all sessions."})
+		return
+	}
+
+	if !keepCurrent {
+		clearAuthCookies(c)
+	}
+
+	c.JSON(200, gin.H{"message": "Logged out of all sessions successfully!"})
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Cookie names used for the two halves of a session.
+const (
+	accessTokenCookie  = "jwt"
+	refreshTokenCookie = "refresh_token"
+)
+
+// startSession registers a new device session for the user and sets the
+// access and refresh token cookies on the response.
+func startSession(c *gin.Context, user models.User) error {
+	familyID, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	// The family ID doubles as the session's jti, tying every access token
+	// and refresh token of this login to one revocable session.
+	var refreshToken string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := services.CreateSession(tx, user.Id, familyID, c.Request.UserAgent(), c.ClientIP()); err != nil {
+			return err
+		}
+		refreshToken, _, err = services.IssueRefreshToken(tx, user.Id, familyID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(strconv.Itoa(int(user.Id)), familyID)
+	if err != nil {
+		return err
+	}
+
+	setAuthCookies(c, accessToken, refreshToken)
+	return nil
+}
+
+// setAuthCookies writes both session cookies. The refresh token is scoped to
+// /api so it is only sent to the API, where refresh and logout read it.
+func setAuthCookies(c *gin.Context, accessToken, refreshToken string) {
+	c.SetCookie(accessTokenCookie, accessToken, int(utils.AccessTokenTTL.Seconds()), "/", "", false, true)
+	c.SetCookie(refreshTokenCookie, refreshToken, int(utils.RefreshTokenTTL.Seconds()), "/api", "", false, true)
+}
+
+// clearAuthCookies expires both session cookies.
+func clearAuthCookies(c *gin.Context) {
+	c.SetCookie(accessTokenCookie, "", -1, "/", "", false, true) // MaxAge -1 immediately expires it
+	c.SetCookie(refreshTokenCookie, "", -1, "/api", "", false, true)
+}
+
+// RefreshTokenController exchanges the refresh token cookie for a new access
+// token and a rotated refresh token.
+func RefreshTokenController(c *gin.Context) {
+	refreshToken, err := c.Cookie(refreshTokenCookie)
+	if err != nil || refreshToken == "" {
+		c.JSON(401, gin.H{"message": "Unauthorized: Refresh token missing."})
+		return
+	}
+
+	newRefreshToken, issued, err := services.RotateRefreshToken(refreshToken)
+	if err != nil {
+		clearAuthCookies(c)
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(401, gin.H{"message": "Unauthorized: Refresh token reuse detected, please log in again."})
+			return
+		}
+		if errors.Is(err, services.ErrRefreshTokenInvalid) {
+			c.JSON(401, gin.H{"message": "Unauthorized: Refresh token is invalid or expired."})
+			return
+		}
+		log.Printf("Error rotating refresh token: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to refresh session due to server error."})
+		return
+	}
+
+	accessToken, err := utils.GenerateAccessToken(strconv.Itoa(int(issued.UserID)), issued.FamilyID)
+	if err != nil {
+		log.Printf("Error generating access token on refresh for user %d: %v\n", issued.UserID, err)
+		c.JSON(500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	setAuthCookies(c, accessToken, newRefreshToken)
+	c.JSON(200, gin.H{"message": "Session refreshed successfully!"})
+}
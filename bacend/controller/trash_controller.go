@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashRetentionDays is reported with trash listings so clients can show when
+// items will be purged.
+func trashRetentionDays() int {
+	return int(services.TrashRetentionPeriod().Hours() / 24)
+}
+
+// respondRestoreError maps the errors of the services.Restore* functions to responses.
+func respondRestoreError(c *gin.Context, err error, item string, id uint64) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(404, gin.H{"message": item + " not found."})
+	case errors.Is(err, services.ErrNotInTrash):
+		c.JSON(400, gin.H{"message": item + " is not in the trash."})
+	case errors.Is(err, services.ErrParentInTrash):
+		c.JSON(409, gin.H{"message": item + " cannot be restored while its post or author is in the trash."})
+	default:
+		log.Printf("Database error restoring %s %d: %v\n", item, id, err)
+		c.JSON(500, gin.H{"message": "Failed to restore " + item + " due to database error."})
+	}
+}
+
+// GetMyTrashedPosts lists the authenticated user's posts that are in the trash.
+func GetMyTrashedPosts(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var posts []models.Blog
+	err := database.DB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").Find(&posts).Error
+	if err != nil {
+		log.Printf("Database error retrieving trashed posts for user %d: %v\n", userID, err)
+		c.JSON(500, gin.H{"message": "Could not retrieve your trash."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": posts, "retention_days": trashRetentionDays()})
+}
+
+// RestoreMyPost takes one of the authenticated user's posts out of the trash.
+func RestoreMyPost(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid post ID format."})
+		return
+	}
+
+	var post models.Blog
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var owned int64
+		if err := tx.Unscoped().Model(&models.Blog{}).Where("id = ? AND user_id = ?", postID, userID).Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		post, err = services.RestorePost(tx, uint(postID))
+		return err
+	})
+	if err != nil {
+		respondRestoreError(c, err, "Post", postID)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Post restored successfully!", "post": post})
+}
+
+// GetTrashedPostsForAdmin lists every post in the trash.
+// Requires the posts.moderate permission.
+func GetTrashedPostsForAdmin(c *gin.Context) {
+	var posts []models.Blog
+	err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Find(&posts).Error
+	if err != nil {
+		log.Printf("Admin: Database error retrieving trashed posts: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve trashed posts."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": posts, "retention_days": trashRetentionDays()})
+}
+
+// RestorePostAsAdmin takes any post, and the comments trashed with it, out of the trash.
+// Requires the posts.moderate permission.
+func RestorePostAsAdmin(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid post ID format."})
+		return
+	}
+
+	var post models.Blog
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		post, err = services.RestorePost(tx, uint(postID))
+		return err
+	})
+	if err != nil {
+		respondRestoreError(c, err, "Post", postID)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Post restored successfully!", "post": post})
+}
+
+// GetTrashedCommentsForAdmin lists every comment in the trash.
+// Requires the comments.moderate permission.
+func GetTrashedCommentsForAdmin(c *gin.Context) {
+	var comments []models.Comment
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").
+		Preload("User", unscoped).Preload("Blog", unscoped).Find(&comments).Error
+	if err != nil {
+		log.Printf("Admin: Database error retrieving trashed comments: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve trashed comments."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": comments, "retention_days": trashRetentionDays()})
+}
+
+// RestoreCommentAsAdmin takes a comment out of the trash.
+// Requires the comments.moderate permission.
+func RestoreCommentAsAdmin(c *gin.Context) {
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid comment ID format."})
+		return
+	}
+
+	comment, err := services.RestoreComment(database.DB, uint(commentID))
+	if err != nil {
+		respondRestoreError(c, err, "Comment", commentID)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Comment restored successfully!", "comment": comment})
+}
+
+// GetTrashedUsersForAdmin lists every user in the trash.
+// Requires the users.manage permission.
+func GetTrashedUsersForAdmin(c *gin.Context) {
+	var users []models.User
+	err := database.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&users).Error
+	if err != nil {
+		log.Printf("Admin: Database error retrieving trashed users: %v\n", err)
+		c.JSON(500, gin.H{"message": "Failed to retrieve trashed users."})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": users, "retention_days": trashRetentionDays()})
+}
+
+// RestoreUserAsAdmin takes a user, and the posts and comments trashed with them,
+// out of the trash. Content that was reassigned or anonymized stays where it is.
+// Requires the users.manage permission.
+func RestoreUserAsAdmin(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"message": "Invalid user ID format."})
+		return
+	}
+
+	var user models.User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		user, err = services.RestoreUser(tx, uint(userID))
+		return err
+	})
+	if err != nil {
+		respondRestoreError(c, err, "User", userID)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User restored successfully!", "user": user})
+}
@@ -0,0 +1,335 @@
+package controller
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// totpIssuer is the account issuer shown in authenticator apps.
+	totpIssuer = "Gin Blog"
+	// mfaPendingTTL is how long the user has to enter their code after the password step.
+	mfaPendingTTL        = 5 * time.Minute
+	recoveryCodesPerUser = 10
+)
+
+var errSecondFactorInvalid = errors.New("invalid two-factor code")
+
+// verifySecondFactor checks a TOTP code, or failing that a recovery code, for
+// the user. Accepted TOTP steps and recovery codes are consumed so they cannot
+// be replayed.
+func verifySecondFactor(tx *gorm.DB, user *models.User, code, recoveryCode string) error {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok || step <= user.TOTPLastStep {
+			return errSecondFactorInvalid
+		}
+		// Conditional update so the same code cannot be accepted twice concurrently.
+		result := tx.Model(user).Where("totp_last_step < ?", step).Update("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errSecondFactorInvalid
+		}
+		return nil
+	}
+
+	if recoveryCode != "" {
+		result := tx.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.Id, utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errSecondFactorInvalid
+		}
+		return nil
+	}
+
+	return errSecondFactorInvalid
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores a fresh set,
+// returning the plain codes to show to the user once.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodesPerUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(utils.NormalizeRecoveryCode(code))}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// SetupTwoFactor starts TOTP enrollment by generating a secret. The secret only
+// takes effect after it is confirmed with EnableTwoFactor.
+func SetupTwoFactor(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	if user.TOTPEnabled {
+		c.JSON(400, gin.H{"message": "Two-factor authentication is already enabled."})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to start two-factor setup."})
+		return
+	}
+
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		log.Printf("Database error saving TOTP secret for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to start two-factor setup."})
+		return
+	}
+
+	uri := utils.TOTPURI(totpIssuer, user.Email, secret)
+	c.JSON(200, gin.H{
+		"message":     "Scan the QR code with your authenticator app, then confirm with a code.",
+		"secret":      secret,
+		"otpauth_uri": uri,
+		"qr_payload":  uri, // encode this string as a QR code on the client
+	})
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator app and
+// returns a fresh set of recovery codes.
+func EnableTwoFactor(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Verification code is required."})
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(400, gin.H{"message": "Two-factor authentication is already enabled."})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(400, gin.H{"message": "Start two-factor setup first."})
+		return
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, &user, input.Code, ""); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errSecondFactorInvalid) {
+			c.JSON(400, gin.H{"message": "Invalid verification code."})
+			return
+		}
+		log.Printf("Error enabling two-factor authentication for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to enable two-factor authentication."})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":        "Two-factor authentication enabled! Store these recovery codes somewhere safe; they will not be shown again.",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor turns TOTP off after re-checking the password and a second factor.
+func DisableTwoFactor(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		Password     string `json:"password" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Password and a verification or recovery code are required."})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(400, gin.H{"message": "Two-factor authentication is not enabled."})
+		return
+	}
+	if err := user.ComparePassword(input.Password); err != nil {
+		c.JSON(400, gin.H{"message": "Incorrect password!"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, &user, input.Code, input.RecoveryCode); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.Id).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errSecondFactorInvalid) {
+			c.JSON(400, gin.H{"message": "Invalid verification or recovery code."})
+			return
+		}
+		log.Printf("Error disabling two-factor authentication for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to disable two-factor authentication."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Two-factor authentication disabled."})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a TOTP code.
+func RegenerateRecoveryCodes(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "Verification code is required."})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(400, gin.H{"message": "Two-factor authentication is not enabled."})
+		return
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, &user, input.Code, ""); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.Id)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errSecondFactorInvalid) {
+			c.JSON(400, gin.H{"message": "Invalid verification code."})
+			return
+		}
+		log.Printf("Error regenerating recovery codes for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Failed to regenerate recovery codes."})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "New recovery codes generated.", "recovery_codes": codes})
+}
+
+// beginMFALogin answers a successful password check for a user with 2FA enabled
+// with a short-lived "mfa pending" token instead of a session.
+func beginMFALogin(c *gin.Context, user models.User) {
+	token, err := utils.GeneratePurposeToken(strconv.Itoa(int(user.Id)), utils.PurposeMFAPending, user.Email, mfaPendingTTL)
+	if err != nil {
+		log.Printf("Error generating MFA pending token for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":      "Enter the code from your authenticator app to finish logging in.",
+		"mfa_required": true,
+		"mfa_token":    token,
+	})
+}
+
+// LoginTwoFactorController completes a two-step login by exchanging the "mfa
+// pending" token and a TOTP or recovery code for a session.
+func LoginTwoFactorController(c *gin.Context) {
+	var input struct {
+		MFAToken     string `json:"mfa_token" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"message": "MFA token and a verification or recovery code are required."})
+		return
+	}
+
+	claims, err := utils.ParsePurposeToken(input.MFAToken, utils.PurposeMFAPending)
+	if err != nil {
+		c.JSON(401, gin.H{"message": "Your login attempt has expired. Please log in again."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.Issuer).Error; err != nil {
+		c.JSON(401, gin.H{"message": "Your login attempt has expired. Please log in again."})
+		return
+	}
+	if !user.TOTPEnabled || user.Email != claims.Email {
+		c.JSON(401, gin.H{"message": "Your login attempt has expired. Please log in again."})
+		return
+	}
+
+	// Second-factor guesses count towards the same lockout as password guesses
+	if wait := services.LoginLockedFor(user.Email, c.ClientIP()); wait > 0 {
+		respondLoginLocked(c, wait)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return verifySecondFactor(tx, &user, input.Code, input.RecoveryCode)
+	})
+	if err != nil {
+		if errors.Is(err, errSecondFactorInvalid) {
+			if wait := services.RecordLoginFailure(user.Email, c.ClientIP()); wait > 0 {
+				respondLoginLocked(c, wait)
+				return
+			}
+			c.JSON(401, gin.H{"message": "Invalid verification or recovery code."})
+			return
+		}
+		log.Printf("Error verifying second factor for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	if err := startSession(c, user); err != nil {
+		log.Printf("Error starting session for user %d: %v\n", user.Id, err)
+		c.JSON(500, gin.H{"message": "Internal server error"})
+		return
+	}
+	services.RecordLoginSuccess(user.Email)
+
+	c.JSON(200, gin.H{
+		"message": "You have logged in successfully!",
+		"user":    user,
+	})
+}
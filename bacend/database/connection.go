@@ -1,7 +1,6 @@
 package database
 
 import (
-	"Gin-Blog-Website/models"
 	"fmt"
 	"log"
 	"os"
@@ -39,17 +38,8 @@ func Connect() {
 		log.Println("PostgreSQL database connected successfully!")
 	}
 
-	// Assign the database connection to the global `DB` variable
+	// Assign the database connection to the global `DB` variable. Tables are
+	// migrated by main, which runs data migrations that need to see the
+	// schema as it was before AutoMigrate first.
 	DB = database
-
-	// Automigrate to create tables based on models
-	err = database.AutoMigrate(
-		&models.User{},
-		&models.Blog{},
-	)
-	if err != nil {
-		log.Fatal("Error during AutoMigrate:", err)
-	} else {
-		log.Println("Database tables migrated successfully!")
-	}
 }
@@ -0,0 +1,40 @@
+// jobs/jobs.go
+package jobs
+
+import (
+	"Gin-Blog-Website/services"
+	"log"
+	"time"
+)
+
+// job is a piece of background maintenance run on a fixed interval.
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+// schedule lists every background job the server runs.
+var schedule = []job{
+	{name: "account-deletion", interval: time.Hour, run: services.ProcessDueAccountDeletions},
+	{name: "trash-purge", interval: time.Hour, run: services.PurgeTrash},
+	{name: "scheduled-publishing", interval: time.Minute, run: services.PublishDuePosts},
+}
+
+// Start runs every scheduled job once and then on its interval, each in its own
+// goroutine. Errors are logged and the job is retried on the next tick.
+func Start() {
+	for _, j := range schedule {
+		go func(j job) {
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+			for {
+				if err := j.run(); err != nil {
+					log.Printf("Background job %s failed: %v\n", j.name, err)
+				}
+				<-ticker.C
+			}
+		}(j)
+	}
+	log.Printf("Started %d background jobs.\n", len(schedule))
+}
@@ -3,30 +3,52 @@ package middleware
 import (
 	"Gin-Blog-Website/database"
 	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
 	"Gin-Blog-Website/utils"
 	"log"
 	"strconv" // <--- NEW: Import strconv for string to uint conversion
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// How a request was authenticated, stored in the context under "authMethod".
+const (
+	AuthMethodSession  = "session"
+	AuthMethodAPIToken = "api_token"
+)
+
+// AuthMiddleware authenticates the request from, in order, an
+// "Authorization: Bearer" header carrying a personal API token or an access
+// token, or the jwt cookie set at login.
 func AuthMiddleware(c *gin.Context) {
-	tokenString, err := c.Cookie("jwt")
-	if err != nil {
-		log.Println("AuthMiddleware: JWT cookie not found or invalid:", err)
-		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized: Not logged in."})
+	tokenString := bearerToken(c)
+	if strings.HasPrefix(tokenString, models.APITokenPrefix) {
+		authenticateAPIToken(c, tokenString)
 		return
 	}
 
-	// Use utils.ParseJwt directly
-	// utils.ParseJwt returns the issuer (which is the user ID as a string) and an error.
-	userIDStr, err := utils.ParseJwt(tokenString)
+	if tokenString == "" {
+		cookie, err := c.Cookie("jwt")
+		if err != nil {
+			log.Println("AuthMiddleware: JWT cookie not found or invalid:", err)
+			c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized: Not logged in."})
+			return
+		}
+		tokenString = cookie
+	}
+
+	// utils.ParseAccessToken only accepts short-lived access tokens.
+	// The issuer claim is the user ID as a string.
+	claims, err := utils.ParseAccessToken(tokenString)
 	if err != nil {
 		log.Println("AuthMiddleware: Failed to parse token or token is invalid:", err)
 		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized: Invalid token."})
 		return
 	}
 
+	userIDStr := claims.Issuer
+
 	// --- NEW: Convert userIDStr to uint ---
 	// The base 10 means decimal, 64 means uint64, which is then cast to uint
 	userID, err := strconv.ParseUint(userIDStr, 10, 64)
@@ -37,22 +59,69 @@ func AuthMiddleware(c *gin.Context) {
 	}
 	// --- END NEW ---
 
+	// Reject tokens whose session has been revoked (logout, "log out everywhere",
+	// admin force-logout) even though the token itself has not expired yet.
+	if _, err := services.ValidateSession(claims.Id, uint(userID), c.ClientIP()); err != nil {
+		log.Println("AuthMiddleware: Session rejected for user", userID, "Error:", err)
+		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized: Session expired or revoked."})
+		return
+	}
+
+	if !setAuthenticatedUser(c, uint(userID)) {
+		return
+	}
+	c.Set("sessionID", claims.Id)
+	c.Set("authMethod", AuthMethodSession)
+
+	c.Next() // Proceed to the next middleware or handler
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header, if any.
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// authenticateAPIToken authenticates a request made with a personal access token.
+// The token's scopes are stored in the context for RequireScope.
+func authenticateAPIToken(c *gin.Context, rawToken string) {
+	token, err := services.AuthenticateAPIToken(rawToken)
+	if err != nil {
+		log.Println("AuthMiddleware: API token rejected:", err)
+		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized: Invalid API token."})
+		return
+	}
+
+	if !setAuthenticatedUser(c, token.UserID) {
+		return
+	}
+	c.Set("authMethod", AuthMethodAPIToken)
+	c.Set("apiTokenScopes", token.ScopeList())
+
+	c.Next()
+}
+
+// setAuthenticatedUser loads the user and stores it in the context. It aborts
+// the request and returns false if the user no longer exists.
+func setAuthenticatedUser(c *gin.Context, userID uint) bool {
 	var user models.User
 	// Fetch the full user object from the database using the converted userID (uint)
 	// GORM will now correctly use the uint ID to query the primary key.
-	if err := database.DB.Where("id = ?", uint(userID)).First(&user).Error; err != nil { // Ensure it's uint(userID)
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
 		log.Println("AuthMiddleware: User not found from token issuer ID:", userID, "Error:", err)
 		c.AbortWithStatusJSON(401, gin.H{"message": "Unauthorized: User not found."})
-		return
+		return false
 	}
 
 	// Store both userID (now as uint)
-	// AND the full user object (for middlewares like AdminMiddleware)
-	c.Set("userID", uint(userID)) // <--- IMPORTANT: Set it as uint here!
+	// AND the full user object (for middlewares like RequirePermission)
+	c.Set("userID", userID) // <--- IMPORTANT: Set it as uint here!
 	c.Set("user", user)
 
 	// Log the user ID as uint
 	log.Printf("AuthMiddleware: User %s (ID: %d, Role: %s) authenticated.", user.Email, user.Id, user.Role)
-
-	c.Next() // Proceed to the next middleware or handler
+	return true
 }
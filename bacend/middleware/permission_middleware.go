@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/services"
+	"log"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission checks that the authenticated user's role grants every
+// listed permission. This middleware must be applied *after* AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Retrieve the user object set by AuthMiddleware
+		userVal, exists := c.Get("user")
+		if !exists {
+			log.Println("RequirePermission: User object not found in context. AuthMiddleware might not have run or failed.")
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server Error: User context missing. Ensure AuthMiddleware runs first."})
+			return
+		}
+
+		// Type assert the user object
+		user, ok := userVal.(models.User)
+		if !ok {
+			log.Printf("RequirePermission: User context is of unexpected type %T.\n", userVal)
+			c.AbortWithStatusJSON(500, gin.H{"message": "Server Error: Invalid user context type."})
+			return
+		}
+
+		for _, permission := range permissions {
+			granted, err := services.HasPermission(user, permission)
+			if err != nil {
+				log.Printf("RequirePermission: Database error checking permission '%s' for user %d: %v", permission, user.Id, err)
+				c.AbortWithStatusJSON(500, gin.H{"message": "Server Error: Failed to check permissions."})
+				return
+			}
+			if !granted {
+				log.Printf("RequirePermission: User %s (ID: %d, Role: %s) lacks permission '%s'.", user.Email, user.Id, user.Role, permission)
+				c.AbortWithStatusJSON(403, gin.H{"message": "Forbidden: Missing permission '" + permission + "'."})
+				return
+			}
+		}
+
+		// Optionally require two-factor authentication from everyone using an
+		// admin permission, whatever their role is called. The enrollment
+		// endpoints need no permission, so they can still set it up.
+		if os.Getenv("REQUIRE_ADMIN_2FA") == "true" && !user.TOTPEnabled {
+			log.Printf("RequirePermission: User %s (ID: %d, Role: %s) denied access, two-factor authentication not enabled.", user.Email, user.Id, user.Role)
+			c.AbortWithStatusJSON(403, gin.H{
+				"message": "Forbidden: Two-factor authentication is required for accounts with admin permissions. Please enable it first.",
+				"code":    "mfa_enrollment_required",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
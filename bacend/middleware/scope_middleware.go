@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope restricts a route group to personal API tokens that were granted
+// scope. Browser sessions are not scoped and always pass.
+// This middleware must be applied *after* AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authMethod") != AuthMethodAPIToken {
+			c.Next()
+			return
+		}
+
+		for _, granted := range c.GetStringSlice("apiTokenScopes") {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+
+		log.Printf("RequireScope: API token of user %v lacks scope '%s' for %s %s.", c.MustGet("userID"), scope, c.Request.Method, c.FullPath())
+		c.AbortWithStatusJSON(403, gin.H{"message": "Forbidden: API token is missing the '" + scope + "' scope."})
+	}
+}
+
+// RequireSession restricts a route group to interactive (browser) sessions, so
+// API tokens cannot manage sessions, two-factor settings or other tokens.
+// This middleware must be applied *after* AuthMiddleware.
+func RequireSession(c *gin.Context) {
+	if c.GetString("authMethod") != AuthMethodSession {
+		c.AbortWithStatusJSON(403, gin.H{"message": "Forbidden: This endpoint requires logging in, API tokens are not accepted."})
+		return
+	}
+	c.Next()
+}
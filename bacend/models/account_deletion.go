@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// What happens to a deleted account's posts and comments.
+const (
+	// ContentPolicyAnonymize keeps the content but moves it to the placeholder deleted user.
+	ContentPolicyAnonymize = "anonymize"
+	// ContentPolicyDelete removes the content together with the account.
+	ContentPolicyDelete = "delete"
+	// ContentPolicyReassign transfers the content to another user. Only admins can choose it.
+	ContentPolicyReassign = "reassign"
+)
+
+// DeletedUserEmail identifies the placeholder account that anonymized content
+// is reassigned to. It has no password and cannot log in.
+const DeletedUserEmail = "deleted-user@deleted.invalid"
+
+// AccountDeletion is a user's pending request to delete their own account.
+// The account is deleted once ScheduledFor has passed, unless the request is
+// cancelled first.
+type AccountDeletion struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	UserID        uint      `json:"user_id" gorm:"uniqueIndex"`
+	ContentPolicy string    `json:"content_policy" gorm:"type:varchar(20)"`
+	ScheduledFor  time.Time `json:"scheduled_for" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// IsValidContentPolicy reports whether policy is one users can pick for their own account.
+func IsValidContentPolicy(policy string) bool {
+	return policy == ContentPolicyAnonymize || policy == ContentPolicyDelete
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// APITokenPrefix starts every personal access token so AuthMiddleware (and
+// secret scanners) can tell them apart from session JWTs.
+const APITokenPrefix = "gbp_"
+
+// API token scopes.
+const (
+	ScopeRead          = "read"
+	ScopeWritePosts    = "write:posts"
+	ScopeWriteComments = "write:comments"
+	ScopeAdmin         = "admin"
+)
+
+// ValidScopes lists every scope a personal access token may be granted.
+var ValidScopes = []string{ScopeRead, ScopeWritePosts, ScopeWriteComments, ScopeAdmin}
+
+// APIToken is a personal access token used for scripting against the API.
+// Only the SHA-256 of the token is stored; Prefix lets users recognise it.
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(20)"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	Scopes     string     `json:"scopes"` // space separated
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ScopeList returns the token's scopes as a slice.
+func (token *APIToken) ScopeList() []string {
+	return strings.Fields(token.Scopes)
+}
+
+// IsActive reports whether the token has neither been revoked nor expired.
+func (token *APIToken) IsActive() bool {
+	if token.RevokedAt != nil {
+		return false
+	}
+	return token.ExpiresAt == nil || time.Now().Before(*token.ExpiresAt)
+}
+
+// IsValidScope reports whether scope is one of ValidScopes.
+func IsValidScope(scope string) bool {
+	for _, valid := range ValidScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
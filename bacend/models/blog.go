@@ -25,8 +25,64 @@ type Blog struct {
 	User        User      `json:"user"` // Belongs To User
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	// NEW: Field for approval status
-	IsApproved bool `json:"is_approved" gorm:"default:false"`
+	// Set when the post is moved to the trash; trashed posts are hidden from
+	// every query unless it is explicitly Unscoped.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	// Lifecycle state, see the PostStatus constants. Only published posts are public.
+	Status string `json:"status" gorm:"type:varchar(20);default:'draft';index"`
+	// The latest note left by a reviewer, e.g. why changes were requested.
+	ReviewNote string `json:"review_note"`
+	// Optional time chosen by the author; an approved post is published once it
+	// has passed. Nil means as soon as it is approved.
+	PublishAt *time.Time `json:"publish_at" gorm:"index"`
+	// When the post actually went public; public listings are ordered by it.
+	PublishedAt *time.Time `json:"published_at" gorm:"index"`
+	// Bumped on every change; sent as the ETag so edits based on a stale copy
+	// can be refused.
+	Version uint `json:"version" gorm:"not null;default:1"`
+	// URL name of the post, unique across posts and their old slugs. Empty
+	// only until BackfillPostSlugs has run.
+	Slug string `json:"slug" gorm:"type:varchar(80);not null;default:'';uniqueIndex:idx_blogs_slug,where:slug <> ''"`
+	// Optional section of the blog the post is filed under.
+	CategoryID *uint     `json:"category_id" gorm:"index"`
+	Category   *Category `json:"category,omitempty"`
+	Tags       []Tag     `json:"tags" gorm:"many2many:blog_tags"`
+	// Counts of approved comments and of likes, filled in by post listings only.
+	CommentCount *int64 `json:"comment_count,omitempty" gorm:"->;-:migration"`
+	LikeCount    *int64 `json:"like_count,omitempty" gorm:"->;-:migration"`
+}
+
+// Post lifecycle states. A post is written as a draft, submitted for review,
+// sent back with changes requested or approved, then published and finally
+// archived. PostTransitions lists the allowed moves.
+const (
+	PostStatusDraft            = "draft"
+	PostStatusSubmitted        = "submitted"
+	PostStatusChangesRequested = "changes_requested"
+	PostStatusApproved         = "approved"
+	PostStatusPublished        = "published"
+	PostStatusArchived         = "archived"
+)
+
+// PostTransitions maps each status to the statuses a post can move to from it.
+// Approved and published posts go back to submitted when their content is edited.
+var PostTransitions = map[string][]string{
+	PostStatusDraft:            {PostStatusSubmitted},
+	PostStatusSubmitted:        {PostStatusApproved, PostStatusChangesRequested, PostStatusDraft},
+	PostStatusChangesRequested: {PostStatusSubmitted, PostStatusDraft},
+	PostStatusApproved:         {PostStatusPublished, PostStatusChangesRequested, PostStatusDraft, PostStatusSubmitted},
+	PostStatusPublished:        {PostStatusArchived, PostStatusSubmitted},
+	PostStatusArchived:         {PostStatusDraft},
+}
+
+// CanTransitionTo reports whether the post may move from its current status to status.
+func (blog *Blog) CanTransitionTo(status string) bool {
+	for _, allowed := range PostTransitions[blog.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
 }
 
 // Ensure Blog also has CreatedAt and UpdatedAt, and soft delete if desired
@@ -29,6 +29,8 @@ type Comment struct {
 	Blog      Blog      `json:"blog"` // Belongs to Blog
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Set when the comment is moved to the trash, see Blog.DeletedAt.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 	// NEW: Field for approval status
 	IsApproved bool `json:"is_approved" gorm:"default:false"`
 }
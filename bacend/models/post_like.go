@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PostLike records that a user liked a post. A user likes a post at most once.
+type PostLike struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	BlogID    uint      `json:"blog_id" gorm:"uniqueIndex:idx_post_like_user"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_post_like_user;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PostRevision is an immutable snapshot of a post's content, taken when the
+// post is created and on every edit. Number counts up from 1 per post.
+type PostRevision struct {
+	ID          uint   `json:"id" gorm:"primarykey"`
+	BlogID      uint   `json:"blog_id" gorm:"uniqueIndex:idx_post_revision_number"`
+	Number      int    `json:"number" gorm:"uniqueIndex:idx_post_revision_number"`
+	EditorID    *uint  `json:"editor_id"` // nil once the editor's account has been erased
+	Editor      *User  `json:"editor,omitempty" gorm:"foreignKey:EditorID"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+	// RestoredFrom is the revision number this one copied, for rollbacks.
+	RestoredFrom *int      `json:"restored_from"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PostSlug is a slug a post used to have. Requests for it are redirected to
+// the post's current slug, so old links keep working. A slug belongs to one
+// post only, whether it is current or old.
+type PostSlug struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	BlogID    uint      `json:"blog_id" gorm:"index"`
+	Slug      string    `json:"slug" gorm:"type:varchar(80);uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
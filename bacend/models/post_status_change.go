@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PostStatusChange records one move of a post through its lifecycle, who made
+// it and any note they left. ActorID is nil for changes made by the server
+// itself, such as scheduled publishing. Rows are never updated.
+type PostStatusChange struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	BlogID     uint      `json:"blog_id" gorm:"index"`
+	ActorID    *uint     `json:"actor_id"`
+	Actor      *User     `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(20)"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
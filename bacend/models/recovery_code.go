@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode is a hashed, single-use backup code for two-factor authentication.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken is a server-side record of an issued refresh token.
+// Tokens issued from the same login share a FamilyID; every refresh revokes
+// the presented token and issues its successor in the same family.
+type RefreshToken struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	FamilyID   string     `json:"family_id" gorm:"type:varchar(64);index"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex"` // SHA-256 of the raw token, never the token itself
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy *uint      `json:"replaced_by"` // ID of the token issued when this one was rotated
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the token can still be exchanged for a new one.
+func (token *RefreshToken) IsActive() bool {
+	return token.RevokedAt == nil && time.Now().Before(token.ExpiresAt)
+}
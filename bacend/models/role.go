@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Permission names. Handlers are guarded by these through middleware.RequirePermission.
+const (
+	PermPostsApprove     = "posts.approve"     // review queue: approve and reject submitted posts
+	PermPostsModerate    = "posts.moderate"    // list and delete any post
+	PermCommentsModerate = "comments.moderate" // approve, reject, list and delete any comment
+	PermUsersManage      = "users.manage"      // list users, change roles, delete and log out users
+	PermRolesManage      = "roles.manage"      // create and edit roles
+	PermCategoriesManage = "categories.manage" // create, edit and delete post categories
+)
+
+// AllPermissions lists every permission known to the application.
+var AllPermissions = []string{
+	PermPostsApprove,
+	PermPostsModerate,
+	PermCommentsModerate,
+	PermUsersManage,
+	PermRolesManage,
+	PermCategoriesManage,
+}
+
+// RoleAdmin is the built-in role that always holds every permission.
+const RoleAdmin = "admin"
+
+// RoleUser is the role given to newly registered users.
+const RoleUser = "user"
+
+// DefaultRoles are created on startup if they do not exist yet.
+var DefaultRoles = map[string][]string{
+	RoleUser:    {},
+	"author":    {},
+	"moderator": {PermCommentsModerate},
+	"editor":    {PermPostsApprove, PermPostsModerate, PermCommentsModerate, PermCategoriesManage},
+	RoleAdmin:   AllPermissions,
+}
+
+// Permission is a single named capability that can be granted to roles.
+type Permission struct {
+	ID   uint   `json:"id" gorm:"primarykey"`
+	Name string `json:"name" gorm:"type:varchar(100);uniqueIndex"`
+}
+
+// Role is a named set of permissions. User.Role holds the role's Name.
+type Role struct {
+	ID          uint         `json:"id" gorm:"primarykey"`
+	Name        string       `json:"name" gorm:"type:varchar(50);uniqueIndex"`
+	Description string       `json:"description"`
+	BuiltIn     bool         `json:"built_in" gorm:"default:false"` // built-in roles cannot be deleted
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// IsValidPermission reports whether name is one of AllPermissions.
+func IsValidPermission(name string) bool {
+	for _, permission := range AllPermissions {
+		if permission == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Session is one logged-in device. JTI is the token ID carried by every access
+// token issued for the session and doubles as its refresh token family ID.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	JTI        string     `json:"-" gorm:"column:jti;type:varchar(64);uniqueIndex"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Current is set when listing sessions to mark the one making the request.
+	Current bool `json:"current" gorm:"-"`
+}
+
+// IsActive reports whether the session has neither been revoked nor expired.
+func (session *Session) IsActive() bool {
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt)
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// MaxPostTags is the most tags a post can carry.
+const MaxPostTags = 10
+
+// Tag is a free-form topic label on posts. Authors create tags simply by
+// using them; Name is stored in slug form, e.g. "web-development".
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Name      string    `json:"name" gorm:"type:varchar(50);uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Category is an admin-managed section of the blog. Categories form a tree
+// through ParentID; a post belongs to at most one category, and browsing a
+// category also shows the posts of its subcategories.
+type Category struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	Name        string    `json:"name" gorm:"type:varchar(100)"`
+	Slug        string    `json:"slug" gorm:"type:varchar(80);uniqueIndex"`
+	Description string    `json:"description"`
+	ParentID    *uint     `json:"parent_id" gorm:"index"`
+	Parent      *Category `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -4,6 +4,7 @@ import (
     "time"
 
     "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
 )
 
 type User struct {
@@ -14,6 +15,13 @@ type User struct {
     Password         []byte    `json:"-"` // Changed to "-" to ensure password hash is never serialized
     Phone            string    `json:"phone,omitempty"`
     Role             string    `json:"role" gorm:"type:varchar(50);default:'user'"`
+    EmailVerifiedAt  *time.Time `json:"email_verified_at"` // nil until the user confirms their address
+
+    // Two-factor authentication (TOTP). TOTPSecret is set during enrollment and
+    // only takes effect once TOTPEnabled is true.
+    TOTPSecret       string    `json:"-"`
+    TOTPEnabled      bool      `json:"two_factor_enabled" gorm:"default:false"`
+    TOTPLastStep     int64     `json:"-"` // last accepted time step, to reject replayed codes
     
     // NEW PROFILE FIELDS
     Bio              string    `json:"bio,omitempty"`               // User's short biography
@@ -23,12 +31,18 @@ type User struct {
 
     CreatedAt        time.Time `json:"created_at"` // Added for consistency
     UpdatedAt        time.Time `json:"updated_at"` // Added for consistency
+    DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"` // set while the account is in the trash
 
     // GORM associations (if you have them)
     Blogs            []Blog    `gorm:"foreignKey:UserID" json:"-"`    // User has many blogs (add if you have Blog model)
     Comments         []Comment `gorm:"foreignKey:UserID" json:"-"` // User has many comments (add if you have Comment model)
 }
 
+// IsEmailVerified reports whether the user has confirmed their email address.
+func (user *User) IsEmailVerified() bool {
+    return user.EmailVerifiedAt != nil
+}
+
 func (user *User) SetPassword(password string) error {
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
     if err != nil {
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// UserIdentity links a User to an account at an external OpenID Connect provider.
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Provider  string    `json:"provider" gorm:"type:varchar(50);uniqueIndex:idx_identity_provider_subject"`
+	Subject   string    `json:"-" gorm:"type:varchar(255);uniqueIndex:idx_identity_provider_subject"` // the provider's stable "sub" claim
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCLoginState holds the per-attempt secrets of an in-flight OIDC login.
+// It is looked up by the hash of the state parameter and deleted on use.
+type OIDCLoginState struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	StateHash    string    `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	Provider     string    `json:"provider" gorm:"type:varchar(50)"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
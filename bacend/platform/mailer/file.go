@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message to its own .eml file in Dir instead of sending it.
+type FileMailer struct {
+	Dir string
+
+	counter atomic.Uint64
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), m.counter.Add(1))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, buildMessage("noreply@localhost", msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write email to %s: %w", path, err)
+	}
+	return nil
+}
+
+// LogMailer prints messages to the server log. It is the default for local development.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("Mailer: To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	return nil
+}
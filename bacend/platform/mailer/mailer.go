@@ -0,0 +1,69 @@
+// mailer/mailer.go
+package mailer
+
+import (
+	"fmt"
+	"os"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default is the mailer used by Send. It is configured by InitMailer.
+var Default Mailer = &LogMailer{}
+
+// InitMailer selects the mail transport from MAIL_DRIVER:
+//   - "smtp": deliver through SMTP_HOST/SMTP_PORT with SMTP_USERNAME/SMTP_PASSWORD, sending as MAIL_FROM
+//   - "file": write each message to MAIL_DIR (default "mail") for local development and tests
+//   - "log" (default): print messages to the server log
+func InitMailer() {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		from := os.Getenv("MAIL_FROM")
+		if host == "" || from == "" {
+			fmt.Println("Warning: SMTP_HOST or MAIL_FROM not set. Falling back to logging emails.")
+			Default = &LogMailer{}
+			return
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		Default = &SMTPMailer{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+		fmt.Println("SMTP mailer initialized successfully.")
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		Default = &FileMailer{Dir: dir}
+		fmt.Printf("File mailer initialized, writing emails to %s.\n", dir)
+	case "", "log":
+		Default = &LogMailer{}
+		fmt.Println("Log mailer initialized, emails will be printed to the server log.")
+	default:
+		fmt.Printf("Warning: unknown MAIL_DRIVER %q. Falling back to logging emails.\n", driver)
+		Default = &LogMailer{}
+	}
+}
+
+// Send delivers msg through the Default mailer.
+func Send(msg Message) error {
+	return Default.Send(msg)
+}
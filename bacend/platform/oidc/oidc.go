@@ -0,0 +1,87 @@
+// oidc/oidc.go
+package oidc
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider is one configured OpenID Connect identity provider.
+// Endpoints and signing keys are discovered lazily from the issuer.
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+var providers = map[string]*Provider{}
+
+// InitProviders loads the providers listed in OIDC_PROVIDERS (comma separated
+// names). Each name is configured through OIDC_<NAME>_ISSUER, _CLIENT_ID,
+// _CLIENT_SECRET, _REDIRECT_URL and optionally _SCOPES (space separated,
+// default "openid email profile"). Any issuer works, including a local mock
+// issuer over plain http for tests.
+func InitProviders() {
+	providers = map[string]*Provider{}
+
+	names := os.Getenv("OIDC_PROVIDERS")
+	if names == "" {
+		fmt.Println("No OIDC providers configured; social login is disabled.")
+		return
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		provider := &Provider{
+			Name:         name,
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			fmt.Printf("Warning: OIDC provider %q is missing ISSUER, CLIENT_ID or REDIRECT_URL and will be skipped.\n", name)
+			continue
+		}
+
+		providers[name] = provider
+		fmt.Printf("OIDC provider %q configured with issuer %s.\n", name, provider.Issuer)
+	}
+}
+
+// GetProvider returns the configured provider with the given name.
+func GetProvider(name string) (*Provider, bool) {
+	provider, ok := providers[strings.ToLower(name)]
+	return provider, ok
+}
+
+// ProviderNames lists the configured providers in alphabetical order.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
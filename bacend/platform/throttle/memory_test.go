@@ -0,0 +1,100 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreIncr(t *testing.T) {
+	store := NewMemoryStore()
+	for want := int64(1); want <= 3; want++ {
+		got, err := store.Incr("k", time.Minute)
+		if err != nil {
+			t.Fatalf("Incr: %v", err)
+		}
+		if got != want {
+			t.Errorf("Incr #%d = %d, want %d", want, got, want)
+		}
+	}
+
+	// Other keys count separately.
+	if got, _ := store.Incr("other", time.Minute); got != 1 {
+		t.Errorf("Incr of a new key = %d, want 1", got)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	store.Incr("k", 20*time.Millisecond)
+	store.Incr("k", 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	if ttl, _ := store.TTL("k"); ttl != 0 {
+		t.Errorf("TTL of an expired key = %s, want 0", ttl)
+	}
+	if got, _ := store.Incr("k", time.Minute); got != 1 {
+		t.Errorf("Incr after expiry = %d, want a fresh count of 1", got)
+	}
+}
+
+func TestMemoryStoreIncrExtendsExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	store.Incr("k", 100*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+	store.Incr("k", 100*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+
+	// 120ms after the first Incr, but only 60ms after the second.
+	if got, _ := store.Incr("k", time.Minute); got != 3 {
+		t.Errorf("Incr = %d, want 3 since every Incr resets the expiry", got)
+	}
+}
+
+func TestMemoryStoreSetTTLDel(t *testing.T) {
+	store := NewMemoryStore()
+
+	tests := []struct {
+		name    string
+		setup   func()
+		key     string
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{"missing key", func() {}, "missing", 0, 0},
+		{"set key", func() { store.Set("set", 7, time.Minute) }, "set", 59 * time.Second, time.Minute},
+		{"deleted key", func() { store.Set("gone", 1, time.Minute); store.Del("gone", "never-set") }, "gone", 0, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setup()
+			ttl, err := store.TTL(tc.key)
+			if err != nil {
+				t.Fatalf("TTL: %v", err)
+			}
+			if ttl < tc.wantMin || ttl > tc.wantMax {
+				t.Errorf("TTL = %s, want between %s and %s", ttl, tc.wantMin, tc.wantMax)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweepsExpiredEntries(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set("old", 1, time.Millisecond)
+	store.Set("live", 1, time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	store.mu.Lock()
+	store.lastSweep = time.Now().Add(-2 * sweepInterval)
+	store.mu.Unlock()
+	store.TTL("live")
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.entries["old"]; ok {
+		t.Error("expired entry was not swept")
+	}
+	if _, ok := store.entries["live"]; !ok {
+		t.Error("live entry was swept")
+	}
+}
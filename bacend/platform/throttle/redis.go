@@ -0,0 +1,197 @@
+package throttle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	redisPoolSize    = 8
+	redisDialTimeout = 2 * time.Second
+	redisIOTimeout   = 2 * time.Second
+)
+
+// RedisStore is a Store backed by any server speaking the Redis protocol
+// (Redis, Valkey, KeyDB, ...). It uses a small pool of plain connections.
+type RedisStore struct {
+	addr     string
+	password string
+	db       int
+	pool     chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisStore returns a store that connects lazily to the server at addr.
+func NewRedisStore(addr, password string, db int) *RedisStore {
+	return &RedisStore{addr: addr, password: password, db: db, pool: make(chan *redisConn, redisPoolSize)}
+}
+
+// redisIncrScript increments a counter and sets its expiry in one step, so a
+// failure or crash in between cannot leave a counter that never expires.
+const redisIncrScript = `local count = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return count`
+
+func (s *RedisStore) Incr(key string, ttl time.Duration) (int64, error) {
+	value, err := s.do("EVAL", redisIncrScript, "1", key, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return 0, err
+	}
+	count, ok := value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %v", value)
+	}
+	return count, nil
+}
+
+func (s *RedisStore) Set(key string, value int64, ttl time.Duration) error {
+	_, err := s.do("SET", key, strconv.FormatInt(value, 10), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (s *RedisStore) TTL(key string) (time.Duration, error) {
+	value, err := s.do("PTTL", key)
+	if err != nil {
+		return 0, err
+	}
+	ms, ok := value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected PTTL reply %v", value)
+	}
+	if ms < 0 { // -2: no such key, -1: no expiry
+		return 0, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (s *RedisStore) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := s.do("DEL", keys...)
+	return err
+}
+
+// do runs one command on a pooled connection. Connections that hit an I/O
+// error are closed instead of being returned to the pool.
+func (s *RedisStore) do(command string, args ...string) (interface{}, error) {
+	conn, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.roundTrip(append([]string{command}, args...))
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.conn.Close()
+		return nil, err
+	}
+	s.put(conn)
+	return reply, err
+}
+
+func (s *RedisStore) get() (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", s.addr, redisDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if s.password != "" {
+		if _, err := conn.roundTrip([]string{"AUTH", s.password}); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if s.db != 0 {
+		if _, err := conn.roundTrip([]string{"SELECT", strconv.Itoa(s.db)}); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (s *RedisStore) put(conn *redisConn) {
+	select {
+	case s.pool <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// roundTrip writes a command as a RESP array and reads one reply.
+func (c *redisConn) roundTrip(args []string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisIOTimeout))
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("redis: malformed reply")
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
@@ -0,0 +1,47 @@
+// throttle/throttle.go
+package throttle
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Store keeps expiring counters for rate limiting and lockouts. Implementations
+// must be safe for concurrent use; the Redis store also shares state between
+// server instances.
+type Store interface {
+	// Incr increments key and (re)sets its expiry to ttl, returning the new value.
+	Incr(key string, ttl time.Duration) (int64, error)
+	// Set stores value at key for ttl.
+	Set(key string, value int64, ttl time.Duration) error
+	// TTL returns how long until key expires, or 0 if it does not exist.
+	TTL(key string) (time.Duration, error)
+	// Del removes keys; missing keys are ignored.
+	Del(keys ...string) error
+}
+
+// Default is the store used by the application. It is configured by InitStore.
+var Default Store = NewMemoryStore()
+
+// InitStore selects the counter store from THROTTLE_STORE:
+//   - "memory" (default): per-process counters, fine for a single instance
+//   - "redis": any Redis-compatible server at REDIS_ADDR, with optional REDIS_PASSWORD and REDIS_DB
+func InitStore() {
+	switch store := os.Getenv("THROTTLE_STORE"); store {
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			fmt.Println("Warning: THROTTLE_STORE=redis but REDIS_ADDR is not set. Using in-memory counters.")
+			return
+		}
+		db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+		Default = NewRedisStore(addr, os.Getenv("REDIS_PASSWORD"), db)
+		fmt.Printf("Throttle store initialized with Redis at %s.\n", addr)
+	case "", "memory":
+		fmt.Println("Throttle store initialized in memory.")
+	default:
+		fmt.Printf("Warning: unknown THROTTLE_STORE %q. Using in-memory counters.\n", store)
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"Gin-Blog-Website/controller"
 	"Gin-Blog-Website/middleware"
+	"Gin-Blog-Website/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,60 +12,187 @@ func Setup(app *gin.Engine) {
 	// Public Routes - Accessible without authentication
 	app.POST("/api/register", controller.RegisterController)
 	app.POST("/api/login", controller.LoginController)
+	app.POST("/api/login/2fa", controller.LoginTwoFactorController)
+	// Refresh and logout only need the refresh token cookie, so they keep
+	// working after the short-lived access token has expired.
+	app.POST("/api/token/refresh", controller.RefreshTokenController)
+	app.POST("/api/logout", controller.LogoutController)
+	app.POST("/api/password/forgot", controller.ForgotPasswordController)
+	app.POST("/api/password/reset", controller.ResetPasswordController)
+	app.POST("/api/email/verify", controller.VerifyEmailController)
+	app.POST("/api/email/change/confirm", controller.ConfirmEmailChange)
+
+	// Social login via OpenID Connect providers
+	app.GET("/api/auth/oidc/providers", controller.GetOIDCProviders)
+	app.GET("/api/auth/oidc/:provider/login", controller.OIDCLoginController)
+	app.GET("/api/auth/oidc/:provider/callback", controller.OIDCCallbackController)
 
 	// Public Post & Comment Viewing (ONLY APPROVED CONTENT)
 	app.GET("/api/posts", controller.GetAllPost)
 	app.GET("/api/posts/:id", controller.GetPostById)
+	app.GET("/api/posts/by-slug/:slug", controller.GetPostBySlug)
+	app.GET("/api/search", controller.SearchPosts)
+	app.GET("/api/categories", controller.GetCategories)
+	app.GET("/api/categories/:slug/posts", controller.GetPostsByCategory)
+	app.GET("/api/tags/:tag/posts", controller.GetPostsByTag)
 	app.GET("/api/posts/:id/comments", controller.GetCommentsByPostID)
 
 	app.GET("/api/users/:id/profile", controller.GetUserProfile)
 
-	// Authenticated User Routes - Requires AuthMiddleware
+	// Public JWT verification keys for other services
+	app.GET("/.well-known/jwks.json", controller.JWKSController)
+
+	// Authenticated User Routes - Requires AuthMiddleware.
+	// Browser sessions can use every route; personal API tokens are limited to
+	// the route groups matching their scopes.
 	auth := app.Group("/api") // Grouping authenticated routes under /api
 	auth.Use(middleware.AuthMiddleware)
+
+	// Read-only routes (scope: read)
+	read := auth.Group("", middleware.RequireScope(models.ScopeRead))
 	{
-		auth.GET("/user", controller.UserGetController)
-		auth.POST("/logout", controller.LogoutController)
+		read.GET("/user", controller.UserGetController)
+		read.GET("/posts/user", controller.GetMyPosts)
+		read.GET("/posts/trash", controller.GetMyTrashedPosts)
+		read.GET("/posts/:id/history", controller.GetMyPostHistory)
+		read.GET("/posts/:id/revisions", controller.GetMyPostRevisions)
+		read.GET("/posts/:id/revisions/:rev", controller.GetMyPostRevision)
+		read.GET("/posts/:id/diff", controller.GetMyPostDiff)
+		read.GET("/my-profile", controller.GetMyProfile)
+	}
 
-		// Post-related routes for authenticated users
-		auth.POST("/posts", controller.CreatePost)
-		auth.GET("/posts/user", controller.GetMyPosts)
-		auth.PUT("/posts/:id", controller.UpdatePostById)
-		auth.DELETE("/posts/:id", controller.DeletePost)
+	// Post-related routes for authenticated users (scope: write:posts)
+	posts := auth.Group("", middleware.RequireScope(models.ScopeWritePosts))
+	{
+		posts.POST("/posts", controller.CreatePost)
+		posts.PUT("/posts/:id", controller.UpdatePostById)
+		posts.DELETE("/posts/:id", controller.DeletePost)
+		posts.POST("/posts/:id/restore", controller.RestoreMyPost)
 
-		// Comment-related route for authenticated users
-		auth.POST("/posts/:id/comments", controller.CreateComment)
+		// Post lifecycle: submit/resubmit for review, back to draft, archive
+		posts.POST("/posts/:id/submit", controller.SubmitMyPost)
+		posts.POST("/posts/:id/withdraw", controller.WithdrawMyPost)
+		posts.POST("/posts/:id/archive", controller.ArchiveMyPost)
 
-		auth.GET("/my-profile", controller.GetMyProfile)
-		auth.PUT("/my-profile", controller.UpdateMyProfile)
+		// Roll a post back to an older revision
+		posts.POST("/posts/:id/revisions/:rev/restore", controller.RestoreMyPostRevision)
 
 		// File Upload route
-		auth.POST("/upload", controller.Upload)
+		posts.POST("/upload", controller.Upload)
+	}
+
+	// Comments and likes for authenticated users (scope: write:comments)
+	comments := auth.Group("", middleware.RequireScope(models.ScopeWriteComments))
+	{
+		comments.POST("/posts/:id/comments", controller.CreateComment)
+		comments.POST("/posts/:id/like", controller.LikePost)
+		comments.DELETE("/posts/:id/like", controller.UnlikePost)
 	}
 
-	// Admin Routes - Require both AuthMiddleware AND AdminMiddleware
+	// Account management - browser sessions only, never API tokens
+	account := auth.Group("", middleware.RequireSession)
+	{
+		account.PUT("/my-profile", controller.UpdateMyProfile)
+		account.PUT("/my-profile/password", controller.ChangeMyPassword)
+		account.POST("/my-profile/email", controller.RequestEmailChange)
+
+		// Data export and self-service account deletion
+		account.GET("/my-profile/export", controller.ExportMyData)
+		account.GET("/my-profile/deletion", controller.GetMyAccountDeletion)
+		account.POST("/my-profile/deletion", controller.ScheduleMyAccountDeletion)
+		account.DELETE("/my-profile/deletion", controller.CancelMyAccountDeletion)
+		account.POST("/email/verify/resend", controller.ResendVerificationEmailController)
+
+		// Two-factor authentication (TOTP) enrollment
+		account.POST("/2fa/setup", controller.SetupTwoFactor)
+		account.POST("/2fa/enable", controller.EnableTwoFactor)
+		account.POST("/2fa/disable", controller.DisableTwoFactor)
+		account.POST("/2fa/recovery-codes", controller.RegenerateRecoveryCodes)
+
+		// Session (device) management
+		account.GET("/sessions", controller.GetMySessions)
+		account.DELETE("/sessions", controller.RevokeAllMySessions)
+		account.DELETE("/sessions/:id", controller.RevokeMySession)
+
+		// Personal API tokens
+		account.GET("/tokens", controller.GetMyAPITokens)
+		account.POST("/tokens", controller.CreateMyAPIToken)
+		account.DELETE("/tokens/:id", controller.RevokeMyAPIToken)
+	}
+
+	// Admin Routes - Require AuthMiddleware (scope: admin) plus the permission
+	// each group needs, so roles can be granted only part of the admin area
 	admin := app.Group("/api/admin")
-	admin.Use(middleware.AuthMiddleware, middleware.AdminMiddleware)
+	admin.Use(middleware.AuthMiddleware, middleware.RequireScope(models.ScopeAdmin))
+
+	// User Management
+	users := admin.Group("", middleware.RequirePermission(models.PermUsersManage))
+	{
+		users.GET("/users", controller.GetAllUsersForAdmin)
+		users.PUT("/users/:id/role", controller.UpdateUserRoleAsAdmin)
+		users.DELETE("/users/:id", controller.DeleteUserAsAdmin)
+		users.POST("/users/:id/logout", controller.ForceLogoutUserAsAdmin)
+		users.POST("/users/:id/unlock", controller.UnlockUserAsAdmin)
+		users.GET("/trash/users", controller.GetTrashedUsersForAdmin)
+		users.POST("/users/:id/restore", controller.RestoreUserAsAdmin)
+	}
+
+	// Role Management
+	roles := admin.Group("", middleware.RequirePermission(models.PermRolesManage))
+	{
+		roles.GET("/permissions", controller.GetPermissionsForAdmin)
+		roles.GET("/roles", controller.GetRolesForAdmin)
+		roles.POST("/roles", controller.CreateRoleAsAdmin)
+		roles.PUT("/roles/:name", controller.UpdateRoleAsAdmin)
+		roles.DELETE("/roles/:name", controller.DeleteRoleAsAdmin)
+	}
+
+	// Content Approval - Posts
+	postReview := admin.Group("", middleware.RequirePermission(models.PermPostsApprove))
+	{
+		postReview.GET("/posts/pending", controller.GetPendingPostsForAdmin)
+		postReview.PUT("/posts/:id/approve", controller.ApprovePostAsAdmin)
+		postReview.PUT("/posts/:id/reject", controller.RejectPostAsAdmin)
+		postReview.POST("/posts/bulk/approve", controller.BulkApprovePostsAsAdmin)
+		postReview.POST("/posts/bulk/reject", controller.BulkRejectPostsAsAdmin)
+		postReview.GET("/posts/:id/history", controller.GetPostHistoryForAdmin)
+		postReview.GET("/posts/:id/revisions", controller.GetPostRevisionsForAdmin)
+		postReview.GET("/posts/:id/revisions/:rev", controller.GetPostRevisionForAdmin)
+		postReview.GET("/posts/:id/diff", controller.GetPostDiffForAdmin)
+	}
+
+	// Categories
+	categories := admin.Group("", middleware.RequirePermission(models.PermCategoriesManage))
+	{
+		categories.POST("/categories", controller.CreateCategoryAsAdmin)
+		categories.PUT("/categories/:id", controller.UpdateCategoryAsAdmin)
+		categories.DELETE("/categories/:id", controller.DeleteCategoryAsAdmin)
+	}
+
+	// Content Moderation - Comments (approval and removal)
+	commentModeration := admin.Group("", middleware.RequirePermission(models.PermCommentsModerate))
+	{
+		commentModeration.GET("/comments/pending", controller.GetPendingCommentsForAdmin)
+		commentModeration.PUT("/comments/:id/approve", controller.ApproveCommentAsAdmin)
+		commentModeration.PUT("/comments/:id/reject", controller.RejectCommentAsAdmin)
+		commentModeration.GET("/comments", controller.GetAllCommentsForAdmin)
+		commentModeration.DELETE("/comments/:id", controller.DeleteCommentAsAdmin)
+		commentModeration.POST("/comments/bulk/approve", controller.BulkApproveCommentsAsAdmin)
+		commentModeration.POST("/comments/bulk/reject", controller.BulkRejectCommentsAsAdmin)
+		commentModeration.POST("/comments/bulk/delete", controller.BulkDeleteCommentsAsAdmin)
+		commentModeration.GET("/trash/comments", controller.GetTrashedCommentsForAdmin)
+		commentModeration.POST("/comments/:id/restore", controller.RestoreCommentAsAdmin)
+	}
+
+	// General Content Moderation - Posts (can view/delete any post, regardless of approval)
+	postModeration := admin.Group("", middleware.RequirePermission(models.PermPostsModerate))
 	{
-		// User Management
-		admin.GET("/users", controller.GetAllUsersForAdmin)
-		admin.PUT("/users/:id/role", controller.UpdateUserRoleAsAdmin)
-		admin.DELETE("/users/:id", controller.DeleteUserAsAdmin)
-
-		// Content Approval - Posts
-		admin.GET("/posts/pending", controller.GetPendingPostsForAdmin)
-		admin.PUT("/posts/:id/approve", controller.ApprovePostAsAdmin)
-		admin.PUT("/posts/:id/reject", controller.RejectPostAsAdmin)
-
-		// Content Approval - Comments
-		admin.GET("/comments/pending", controller.GetPendingCommentsForAdmin)
-		admin.PUT("/comments/:id/approve", controller.ApproveCommentAsAdmin)
-		admin.PUT("/comments/:id/reject", controller.RejectCommentAsAdmin)
-
-		// General Admin Content Moderation (can view/delete any content, regardless of approval)
-		admin.GET("/posts", controller.GetAllPostsForAdmin)
-		admin.DELETE("/posts/:id", controller.DeletePostAsAdmin)
-		admin.GET("/comments", controller.GetAllCommentsForAdmin)
-		admin.DELETE("/comments/:id", controller.DeleteCommentAsAdmin)
+		postModeration.GET("/posts", controller.GetAllPostsForAdmin)
+		postModeration.DELETE("/posts/:id", controller.DeletePostAsAdmin)
+		postModeration.PUT("/posts/:id/archive", controller.ArchivePostAsAdmin)
+		postModeration.POST("/posts/bulk/archive", controller.BulkArchivePostsAsAdmin)
+		postModeration.POST("/posts/bulk/delete", controller.BulkDeletePostsAsAdmin)
+		postModeration.GET("/trash/posts", controller.GetTrashedPostsForAdmin)
+		postModeration.POST("/posts/:id/restore", controller.RestorePostAsAdmin)
 	}
 }
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultDeletionGraceDays is used when ACCOUNT_DELETION_GRACE_DAYS is unset.
+const defaultDeletionGraceDays = 14
+
+var (
+	// ErrCannotDeletePlaceholder is returned when asked to delete the deleted user placeholder.
+	ErrCannotDeletePlaceholder = errors.New("the deleted user placeholder cannot be deleted")
+	// ErrInvalidReassignTarget is returned when content would be reassigned to a
+	// missing user or to the user being deleted.
+	ErrInvalidReassignTarget = errors.New("invalid user to reassign content to")
+	// errDeletionSkipped marks a due deletion that was cancelled or is being handled elsewhere.
+	errDeletionSkipped = errors.New("account deletion skipped")
+)
+
+// AccountDeletionGracePeriod returns how long a self-deletion request waits
+// before it is carried out, from ACCOUNT_DELETION_GRACE_DAYS (default 14).
+func AccountDeletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultDeletionGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ScheduleAccountDeletion records (or replaces) the user's request to delete
+// their account after the grace period.
+func ScheduleAccountDeletion(userID uint, contentPolicy string) (*models.AccountDeletion, error) {
+	deletion := models.AccountDeletion{
+		UserID:        userID,
+		ContentPolicy: contentPolicy,
+		ScheduledFor:  time.Now().Add(AccountDeletionGracePeriod()),
+	}
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content_policy", "scheduled_for", "created_at"}),
+	}).Create(&deletion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// CancelAccountDeletion withdraws a pending deletion request. It reports
+// whether there was one to cancel.
+func CancelAccountDeletion(userID uint) (bool, error) {
+	result := database.DB.Where("user_id = ?", userID).Delete(&models.AccountDeletion{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ProcessDueAccountDeletions deletes every account whose grace period has
+// passed. Each account is deleted in its own transaction so one failure does
+// not hold up the rest.
+func ProcessDueAccountDeletions() error {
+	var due []models.AccountDeletion
+	if err := database.DB.Where("scheduled_for <= ?", time.Now()).Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, deletion := range due {
+		var email string
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// Re-check under a row lock: the request may have been cancelled since,
+			// or another server instance may already be processing it.
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("id = ? AND scheduled_for <= ?", deletion.ID, time.Now()).
+				First(&deletion).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errDeletionSkipped
+			}
+			if err != nil {
+				return err
+			}
+
+			var user models.User
+			if err := tx.First(&user, deletion.UserID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return tx.Delete(&deletion).Error
+				}
+				return err
+			}
+			email = user.Email
+			return EraseAccount(tx, user, deletion.ContentPolicy)
+		})
+		if errors.Is(err, errDeletionSkipped) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Error deleting account of user %d: %v\n", deletion.UserID, err)
+			continue
+		}
+		log.Printf("Deleted account of user %d (content: %s).\n", deletion.UserID, deletion.ContentPolicy)
+		// Clear any login lockout so a future account with this address starts fresh.
+		if email != "" {
+			ResetLoginFailures(email)
+		}
+	}
+	return nil
+}
+
+// DeletedUser returns the placeholder account anonymized content belongs to,
+// creating it on first use.
+func DeletedUser(tx *gorm.DB) (models.User, error) {
+	placeholder := models.User{
+		FirstName: "Deleted",
+		LastName:  "User",
+		Email:     models.DeletedUserEmail,
+		Role:      models.RoleUser,
+	}
+	err := tx.Where("email = ?", models.DeletedUserEmail).FirstOrCreate(&placeholder).Error
+	return placeholder, err
+}
+
+// AccountContentCounts is how much content deleting an account touches.
+type AccountContentCounts struct {
+	Posts    int64 `json:"posts"`
+	Comments int64 `json:"comments"`
+	// CommentsOnPosts counts other users' comments on the account's posts,
+	// which are removed too when the posts are deleted.
+	CommentsOnPosts int64 `json:"comments_on_posts"`
+}
+
+// CountAccountContent reports the posts and comments that deleting userID affects.
+func CountAccountContent(tx *gorm.DB, userID uint) (AccountContentCounts, error) {
+	var counts AccountContentCounts
+	if err := tx.Model(&models.Blog{}).Where("user_id = ?", userID).Count(&counts.Posts).Error; err != nil {
+		return counts, err
+	}
+	if err := tx.Model(&models.Comment{}).Where("user_id = ?", userID).Count(&counts.Comments).Error; err != nil {
+		return counts, err
+	}
+	postIDs := tx.Model(&models.Blog{}).Select("id").Where("user_id = ?", userID)
+	err := tx.Model(&models.Comment{}).Where("user_id <> ? AND blog_id IN (?)", userID, postIDs).Count(&counts.CommentsOnPosts).Error
+	return counts, err
+}
+
+// DeleteAccount moves a user to the trash and ends all of their logins. Their
+// posts and comments are trashed along with them, moved to the deleted user
+// placeholder, or, with ContentPolicyReassign, moved to the user reassignTo.
+// RestoreUser undoes it until the trash is purged. Run it inside a transaction
+// so a failure leaves nothing half deleted.
+func DeleteAccount(tx *gorm.DB, user models.User, contentPolicy string, reassignTo uint) error {
+	return removeAccount(tx, user, contentPolicy, reassignTo, false)
+}
+
+// EraseAccount permanently deletes a user and everything tied to them, including
+// anything already in the trash. contentPolicy is ContentPolicyAnonymize or
+// ContentPolicyDelete. Run it inside a transaction.
+func EraseAccount(tx *gorm.DB, user models.User, contentPolicy string) error {
+	return removeAccount(tx, user, contentPolicy, 0, true)
+}
+
+func removeAccount(tx *gorm.DB, user models.User, contentPolicy string, reassignTo uint, permanent bool) error {
+	if user.Email == models.DeletedUserEmail {
+		return ErrCannotDeletePlaceholder
+	}
+
+	switch contentPolicy {
+	case models.ContentPolicyAnonymize:
+		placeholder, err := DeletedUser(tx)
+		if err != nil {
+			return err
+		}
+		if err := reassignContent(tx, user.Id, placeholder.Id); err != nil {
+			return err
+		}
+	case models.ContentPolicyReassign:
+		if reassignTo == 0 || reassignTo == user.Id {
+			return ErrInvalidReassignTarget
+		}
+		var target models.User
+		if err := tx.First(&target, reassignTo).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidReassignTarget
+			}
+			return err
+		}
+		if err := reassignContent(tx, user.Id, target.Id); err != nil {
+			return err
+		}
+	case models.ContentPolicyDelete:
+		if permanent {
+			// Comments by others on the user's posts go with the posts.
+			postIDs := tx.Unscoped().Model(&models.Blog{}).Select("id").Where("user_id = ?", user.Id)
+			if err := tx.Unscoped().Where("user_id = ? OR blog_id IN (?)", user.Id, postIDs).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostStatusChange{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostRevision{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostSlug{}).Error; err != nil {
+				return err
+			}
+			if err := deletePostTags(tx, postIDs); err != nil {
+				return err
+			}
+			if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostLike{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_id = ?", user.Id).Delete(&models.Blog{}).Error; err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown content policy %q", contentPolicy)
+	}
+
+	if permanent {
+		// Review and revision history on other users' posts stays, but no
+		// longer names this user.
+		placeholder, err := DeletedUser(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PostStatusChange{}).Where("actor_id = ?", user.Id).Update("actor_id", placeholder.Id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PostRevision{}).Where("editor_id = ?", user.Id).Update("editor_id", placeholder.Id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.Id).Delete(&models.PostLike{}).Error; err != nil {
+			return err
+		}
+	}
+
+	loginRecords := []interface{}{
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.PasswordResetToken{},
+		&models.APIToken{},
+		&models.AccountDeletion{},
+	}
+	if permanent {
+		// Kept while in the trash so a restored account can still use 2FA and social login.
+		loginRecords = append(loginRecords, &models.RecoveryCode{}, &models.UserIdentity{})
+	}
+	for _, model := range loginRecords {
+		if err := tx.Where("user_id = ?", user.Id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	if permanent {
+		return tx.Unscoped().Delete(&user).Error
+	}
+	return trashUser(tx, user, contentPolicy == models.ContentPolicyDelete)
+}
+
+// reassignContent moves every post and comment of one user to another,
+// including trashed ones, so nothing is left pointing at the old user.
+func reassignContent(tx *gorm.DB, fromUserID, toUserID uint) error {
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("user_id = ?", fromUserID).Update("user_id", toUserID).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", fromUserID).Update("user_id", toUserID).Error
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exportedImage is one uploaded image URL in a data export, with where it is used.
+type exportedImage struct {
+	URL    string `json:"url"`
+	UsedBy string `json:"used_by"`
+}
+
+// BuildAccountExport packages everything stored about a user into a ZIP archive:
+// their profile, posts, comments and uploaded image URLs, each as JSON for
+// machines and Markdown for people.
+func BuildAccountExport(userID uint) ([]byte, error) {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	user.Password = nil
+
+	var posts []models.Blog
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	var comments []models.Comment
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	var identities []models.UserIdentity
+	if err := database.DB.Where("user_id = ?", userID).Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	images := []exportedImage{}
+	if user.ProfilePictureURL != "" {
+		images = append(images, exportedImage{URL: user.ProfilePictureURL, UsedBy: "profile picture"})
+	}
+	for _, post := range posts {
+		if post.Image != "" {
+			images = append(images, exportedImage{URL: post.Image, UsedBy: fmt.Sprintf("post %d", post.ID)})
+		}
+	}
+
+	files := map[string]interface{}{
+		"profile.json": map[string]interface{}{
+			"user":            user,
+			"linked_accounts": identities,
+			"exported_at":     time.Now().UTC(),
+		},
+		"posts.json":    posts,
+		"comments.json": comments,
+		"images.json":   images,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(files) {
+		encoded, err := json.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeZipFile(archive, name, encoded); err != nil {
+			return nil, err
+		}
+	}
+
+	markdown := map[string]string{
+		"profile.md":  profileMarkdown(user, identities, images),
+		"comments.md": commentsMarkdown(comments),
+	}
+	for _, post := range posts {
+		markdown[fmt.Sprintf("posts/%d.md", post.ID)] = postMarkdown(post)
+	}
+	for _, name := range sortedKeys(markdown) {
+		if err := writeZipFile(archive, name, []byte(markdown[name])); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sortedKeys gives the archive a stable file order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeZipFile(archive *zip.Writer, name string, content []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func profileMarkdown(user models.User, identities []models.UserIdentity, images []exportedImage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s\n\n", user.FirstName, user.LastName)
+	fmt.Fprintf(&b, "- Email: %s\n", user.Email)
+	fmt.Fprintf(&b, "- Phone: %s\n", user.Phone)
+	fmt.Fprintf(&b, "- Role: %s\n", user.Role)
+	fmt.Fprintf(&b, "- Location: %s\n", user.Location)
+	fmt.Fprintf(&b, "- Website: %s\n", user.Website)
+	fmt.Fprintf(&b, "- Two-factor authentication: %t\n", user.TOTPEnabled)
+	fmt.Fprintf(&b, "- Member since: %s\n", user.CreatedAt.Format(time.RFC3339))
+	if user.Bio != "" {
+		fmt.Fprintf(&b, "\n## Bio\n\n%s\n", user.Bio)
+	}
+	if len(identities) > 0 {
+		b.WriteString("\n## Linked accounts\n\n")
+		for _, identity := range identities {
+			fmt.Fprintf(&b, "- %s (%s)\n", identity.Provider, identity.Email)
+		}
+	}
+	if len(images) > 0 {
+		b.WriteString("\n## Uploaded images\n\n")
+		for _, image := range images {
+			fmt.Fprintf(&b, "- %s: %s\n", image.UsedBy, image.URL)
+		}
+	}
+	return b.String()
+}
+
+func postMarkdown(post models.Blog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", post.Title)
+	fmt.Fprintf(&b, "_Created %s, last updated %s_\n\n", post.CreatedAt.Format(time.RFC3339), post.UpdatedAt.Format(time.RFC3339))
+	if post.Image != "" {
+		fmt.Fprintf(&b, "![](%s)\n\n", post.Image)
+	}
+	b.WriteString(post.Description)
+	b.WriteString("\n")
+	return b.String()
+}
+
+func commentsMarkdown(comments []models.Comment) string {
+	var b strings.Builder
+	b.WriteString("# Comments\n")
+	for _, comment := range comments {
+		fmt.Fprintf(&b, "\n## On post %d, %s\n\n%s\n", comment.BlogID, comment.CreatedAt.Format(time.RFC3339), comment.Content)
+	}
+	return b.String()
+}
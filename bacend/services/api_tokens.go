@@ -0,0 +1,62 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAPITokenInvalid is returned for unknown, expired or revoked API tokens.
+var ErrAPITokenInvalid = errors.New("API token is invalid, expired or revoked")
+
+// apiTokenTouchInterval throttles LastUsedAt writes.
+const apiTokenTouchInterval = time.Minute
+
+// CreateAPIToken issues a personal access token and returns the raw token,
+// which is shown to the user exactly once.
+func CreateAPIToken(userID uint, name string, scopes []string, expiresAt *time.Time) (string, *models.APIToken, error) {
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	raw := models.APITokenPrefix + secret
+
+	token := models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(models.APITokenPrefix)+8],
+		TokenHash: utils.HashToken(raw),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: expiresAt,
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+	return raw, &token, nil
+}
+
+// AuthenticateAPIToken looks up an active token by its raw value and records its use.
+func AuthenticateAPIToken(raw string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := database.DB.Where("token_hash = ?", utils.HashToken(raw)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPITokenInvalid
+		}
+		return nil, err
+	}
+	if !token.IsActive() {
+		return nil, ErrAPITokenInvalid
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > apiTokenTouchInterval {
+		now := time.Now()
+		token.LastUsedAt = &now
+		database.DB.Model(&token).Update("last_used_at", now)
+	}
+	return &token, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// MigrateEmailVerification adds users.email_verified_at to databases created
+// before email verification existed and counts the accounts already there as
+// verified at their creation time, so they can keep posting and commenting.
+// It must run before AutoMigrate and only does work while the column is
+// missing, so accounts registered later still have to verify their address.
+func MigrateEmailVerification() error {
+	migrator := database.DB.Migrator()
+	if !migrator.HasTable(&models.User{}) || migrator.HasColumn(&models.User{}, "email_verified_at") {
+		return nil
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&models.User{}, "EmailVerifiedAt"); err != nil {
+			return err
+		}
+		result := tx.Exec("UPDATE users SET email_verified_at = created_at")
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("Marked %d existing users as having a verified email address.\n", result.RowsAffected)
+		return nil
+	})
+}
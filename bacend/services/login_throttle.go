@@ -0,0 +1,102 @@
+package services
+
+import (
+	"Gin-Blog-Website/platform/throttle"
+	"log"
+	"strings"
+	"time"
+)
+
+// Login throttling policy. Failures are counted per account and per client IP
+// in a sliding window; once a counter reaches its threshold every further
+// failure locks that key out for twice as long as the previous one.
+const (
+	loginFailureWindow    = time.Hour
+	accountFailureLimit   = 5
+	ipFailureLimit        = 20
+	loginLockoutBase      = 30 * time.Second
+	loginLockoutMax       = time.Hour
+	accountThrottlePrefix = "login:account:"
+	ipThrottlePrefix      = "login:ip:"
+	failureCounterSuffix  = ":failures"
+	lockoutMarkerSuffix   = ":locked"
+)
+
+// normalizeLoginEmail makes "Foo@Example.com " and "foo@example.com" share a counter.
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginLockedFor returns how long logins for email from ip are blocked, or 0
+// if they are allowed. Store errors are logged and fail open so an unreachable
+// Redis does not lock everyone out.
+func LoginLockedFor(email, ip string) time.Duration {
+	var wait time.Duration
+	for _, key := range []string{
+		accountThrottlePrefix + normalizeLoginEmail(email) + lockoutMarkerSuffix,
+		ipThrottlePrefix + ip + lockoutMarkerSuffix,
+	} {
+		ttl, err := throttle.Default.TTL(key)
+		if err != nil {
+			log.Printf("Error reading login lockout %s: %v\n", key, err)
+			continue
+		}
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait
+}
+
+// RecordLoginFailure counts a failed login for email and ip, locking either
+// out once it crosses its threshold. It returns the resulting lockout, if any.
+func RecordLoginFailure(email, ip string) time.Duration {
+	accountLock := recordFailure(accountThrottlePrefix+normalizeLoginEmail(email), accountFailureLimit)
+	ipLock := recordFailure(ipThrottlePrefix+ip, ipFailureLimit)
+	if ipLock > accountLock {
+		return ipLock
+	}
+	return accountLock
+}
+
+func recordFailure(key string, limit int64) time.Duration {
+	failures, err := throttle.Default.Incr(key+failureCounterSuffix, loginFailureWindow)
+	if err != nil {
+		log.Printf("Error counting login failure for %s: %v\n", key, err)
+		return 0
+	}
+	if failures < limit {
+		return 0
+	}
+
+	lockout := loginLockoutBase
+	for i := limit; i < failures && lockout < loginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > loginLockoutMax {
+		lockout = loginLockoutMax
+	}
+
+	if err := throttle.Default.Set(key+lockoutMarkerSuffix, failures, lockout); err != nil {
+		log.Printf("Error locking out %s: %v\n", key, err)
+		return 0
+	}
+	log.Printf("Login locked for %s after %d failures, for %s.\n", key, failures, lockout)
+	return lockout
+}
+
+// RecordLoginSuccess clears the account's failure count. The IP counter is
+// left alone so one valid account cannot be used to reset a spraying client.
+func RecordLoginSuccess(email string) {
+	ResetLoginFailures(email)
+}
+
+// ResetLoginFailures removes the failure count and any lockout for an account.
+func ResetLoginFailures(email string) error {
+	key := accountThrottlePrefix + normalizeLoginEmail(email)
+	err := throttle.Default.Del(key+failureCounterSuffix, key+lockoutMarkerSuffix)
+	if err != nil {
+		log.Printf("Error resetting login failures for %s: %v\n", key, err)
+	}
+	return err
+}
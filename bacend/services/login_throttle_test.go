@@ -0,0 +1,122 @@
+package services
+
+import (
+	"Gin-Blog-Website/platform/throttle"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// useMemoryThrottle gives the test a fresh in-memory throttle store.
+func useMemoryThrottle(t *testing.T) {
+	previous := throttle.Default
+	throttle.Default = throttle.NewMemoryStore()
+	t.Cleanup(func() { throttle.Default = previous })
+}
+
+func TestRecordLoginFailureEscalation(t *testing.T) {
+	useMemoryThrottle(t)
+
+	// Failure n locks the account out for the duration at index n-1.
+	want := []time.Duration{
+		0, 0, 0, 0,
+		30 * time.Second,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		16 * time.Minute,
+		32 * time.Minute,
+		time.Hour, // 64 minutes, capped
+		time.Hour,
+	}
+	for i, wantLock := range want {
+		if got := RecordLoginFailure("jo@example.com", "198.51.100.1"); got != wantLock {
+			t.Errorf("failure %d: lockout %s, want %s", i+1, got, wantLock)
+		}
+	}
+}
+
+func TestLoginLockedFor(t *testing.T) {
+	useMemoryThrottle(t)
+
+	for i := 0; i < accountFailureLimit-1; i++ {
+		RecordLoginFailure("jo@example.com", "198.51.100.1")
+	}
+	if wait := LoginLockedFor("jo@example.com", "198.51.100.1"); wait != 0 {
+		t.Fatalf("locked for %s below the limit, want 0", wait)
+	}
+
+	RecordLoginFailure("jo@example.com", "198.51.100.1")
+	tests := []struct {
+		name   string
+		email  string
+		ip     string
+		locked bool
+	}{
+		{"same account and IP", "jo@example.com", "198.51.100.1", true},
+		{"same account from another IP", "jo@example.com", "203.0.113.9", true},
+		{"account in other case and spacing", " JO@Example.com ", "203.0.113.9", true},
+		{"other account from another IP", "sam@example.com", "203.0.113.9", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wait := LoginLockedFor(tc.email, tc.ip)
+			if tc.locked && (wait <= 0 || wait > loginLockoutBase) {
+				t.Errorf("locked for %s, want up to %s", wait, loginLockoutBase)
+			}
+			if !tc.locked && wait != 0 {
+				t.Errorf("locked for %s, want 0", wait)
+			}
+		})
+	}
+}
+
+func TestIPLockoutAcrossAccounts(t *testing.T) {
+	useMemoryThrottle(t)
+
+	ip := "198.51.100.7"
+	for i := 1; i < ipFailureLimit; i++ {
+		// A new account each time, so only the IP counter reaches its limit.
+		email := fmt.Sprintf("user%d@example.com", i)
+		if got := RecordLoginFailure(email, ip); got != 0 {
+			t.Fatalf("failure %d: lockout %s before the IP limit", i, got)
+		}
+	}
+	if got := RecordLoginFailure("last@example.com", ip); got != loginLockoutBase {
+		t.Errorf("failure %d: lockout %s, want %s", ipFailureLimit, got, loginLockoutBase)
+	}
+	if wait := LoginLockedFor("fresh@example.com", ip); wait == 0 {
+		t.Error("an IP over its limit is not locked out for other accounts")
+	}
+	if wait := LoginLockedFor("fresh@example.com", "203.0.113.9"); wait != 0 {
+		t.Errorf("another IP is locked out for %s", wait)
+	}
+}
+
+func TestRecordLoginSuccessResetsOnlyTheAccount(t *testing.T) {
+	useMemoryThrottle(t)
+
+	ip := "198.51.100.1"
+	for i := 0; i < accountFailureLimit; i++ {
+		RecordLoginFailure("jo@example.com", ip)
+	}
+	RecordLoginSuccess("Jo@Example.com")
+
+	if wait := LoginLockedFor("jo@example.com", "203.0.113.9"); wait != 0 {
+		t.Errorf("account still locked for %s after a successful login", wait)
+	}
+	// The account counts from zero again ...
+	for i := 1; i < accountFailureLimit; i++ {
+		if got := RecordLoginFailure("jo@example.com", "203.0.113.9"); got != 0 {
+			t.Fatalf("failure %d after reset: lockout %s, want 0", i, got)
+		}
+	}
+	// ... but the IP keeps the failures it made before the reset.
+	for i := 0; i < ipFailureLimit-accountFailureLimit-1; i++ {
+		RecordLoginFailure(fmt.Sprintf("other%d@example.com", i), ip)
+	}
+	if got := RecordLoginFailure("another@example.com", ip); got != loginLockoutBase {
+		t.Errorf("IP lockout %s after the reset, want %s", got, loginLockoutBase)
+	}
+}
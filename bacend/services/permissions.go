@@ -0,0 +1,125 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+
+	"gorm.io/gorm"
+)
+
+// SeedRoles makes sure every known permission and default role exists. The
+// built-in admin role is always re-synced to hold every permission, so admins
+// can never be locked out by role edits or newly added permissions. A
+// permission added in a new release is also granted to the existing default
+// roles that list it, once, when it is created; grants removed by operators
+// afterwards stay removed.
+func SeedRoles() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		permissions := map[string]models.Permission{}
+		added := map[string]bool{}
+		for _, name := range models.AllPermissions {
+			permission := models.Permission{Name: name}
+			result := tx.Where(models.Permission{Name: name}).FirstOrCreate(&permission)
+			if result.Error != nil {
+				return result.Error
+			}
+			permissions[name] = permission
+			added[name] = result.RowsAffected > 0
+		}
+
+		for name, permissionNames := range models.DefaultRoles {
+			var role models.Role
+			result := tx.Where("name = ?", name).Limit(1).Find(&role)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			grants := make([]models.Permission, 0, len(permissionNames))
+			for _, permissionName := range permissionNames {
+				grants = append(grants, permissions[permissionName])
+			}
+
+			if result.RowsAffected == 0 {
+				role = models.Role{Name: name, BuiltIn: true, Permissions: grants}
+				if err := tx.Create(&role).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			if name == models.RoleAdmin {
+				if err := tx.Model(&role).Association("Permissions").Replace(grants); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var newGrants []models.Permission
+			for _, grant := range grants {
+				if added[grant.Name] {
+					newGrants = append(newGrants, grant)
+				}
+			}
+			if len(newGrants) > 0 {
+				if err := tx.Model(&role).Association("Permissions").Append(newGrants); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// RolePermissions returns the names of the permissions granted to a role.
+func RolePermissions(roleName string) ([]string, error) {
+	var names []string
+	err := database.DB.Table("permissions").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", roleName).
+		Order("permissions.name").
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
+
+// MissingPermissions returns the permissions granted to roleName that the
+// user's own role does not grant.
+func MissingPermissions(user models.User, roleName string) ([]string, error) {
+	wanted, err := RolePermissions(roleName)
+	if err != nil {
+		return nil, err
+	}
+	return PermissionsNotHeld(user, wanted)
+}
+
+// PermissionsNotHeld returns the permissions in wanted that the user's own
+// role does not grant.
+func PermissionsNotHeld(user models.User, wanted []string) ([]string, error) {
+	held, err := RolePermissions(user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	holds := make(map[string]bool, len(held))
+	for _, name := range held {
+		holds[name] = true
+	}
+	var missing []string
+	for _, name := range wanted {
+		if !holds[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// HasPermission reports whether the user's role grants the permission.
+func HasPermission(user models.User, permission string) (bool, error) {
+	var count int64
+	err := database.DB.Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("roles.name = ? AND permissions.name = ?", user.Role, permission).
+		Count(&count).Error
+	return count > 0, err
+}
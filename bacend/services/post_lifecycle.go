@@ -0,0 +1,160 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrIllegalTransition is returned when a post cannot move to the requested status.
+	ErrIllegalTransition = errors.New("illegal post status transition")
+	// ErrPostModified is returned when a post was changed by someone else
+	// after it was loaded.
+	ErrPostModified = errors.New("post was modified concurrently")
+)
+
+// TransitionPost moves post to status and records the change in its history.
+// actorID is the user making the change, or 0 for the server itself.
+// Reviewer notes replace the post's ReviewNote; author actions leave the last
+// note in place so it stays visible while the author works on it.
+// The update is conditional on the version the post was loaded with, so two
+// concurrent changes cannot both succeed.
+func TransitionPost(tx *gorm.DB, post *models.Blog, status string, actorID uint, note string, byReviewer bool) error {
+	if !post.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s to %s", ErrIllegalTransition, post.Status, status)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": status, "version": gorm.Expr("version + 1")}
+	if byReviewer {
+		updates["review_note"] = note
+	}
+	if status == models.PostStatusPublished {
+		// Posts re-published after an edit keep their original publication time.
+		updates["published_at"] = gorm.Expr("COALESCE(published_at, ?)", now)
+	}
+	result := tx.Model(&models.Blog{}).Where("id = ? AND version = ?", post.ID, post.Version).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostModified
+	}
+
+	change := models.PostStatusChange{
+		BlogID:     post.ID,
+		FromStatus: post.Status,
+		ToStatus:   status,
+		Note:       note,
+	}
+	if actorID != 0 { // 0 is the server itself, e.g. the publishing scheduler
+		change.ActorID = &actorID
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return err
+	}
+
+	post.Status = status
+	post.Version++
+	if byReviewer {
+		post.ReviewNote = note
+	}
+	if status == models.PostStatusPublished && post.PublishedAt == nil {
+		post.PublishedAt = &now
+	}
+	return nil
+}
+
+// ApprovePost approves a submitted post on behalf of reviewerID, keeping note
+// as the reviewer note. Posts without a publish time in the future are
+// published right away; the others are left to the scheduler.
+func ApprovePost(tx *gorm.DB, post *models.Blog, reviewerID uint, note string) error {
+	if err := TransitionPost(tx, post, models.PostStatusApproved, reviewerID, note, true); err != nil {
+		return err
+	}
+	if !IsDueForPublishing(*post) {
+		return nil
+	}
+	return TransitionPost(tx, post, models.PostStatusPublished, reviewerID, "", false)
+}
+
+// IsDueForPublishing reports whether an approved post's publish time has come.
+func IsDueForPublishing(post models.Blog) bool {
+	return post.PublishAt == nil || !post.PublishAt.After(time.Now())
+}
+
+// publishBatchSize caps how many posts one scheduler run claims at a time.
+const publishBatchSize = 50
+
+// PublishDuePosts publishes approved posts whose publish time has passed.
+// Posts are claimed with FOR UPDATE SKIP LOCKED, so several server instances
+// can run the scheduler at once without publishing a post twice or blocking
+// each other.
+func PublishDuePosts() error {
+	for {
+		var published int
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var due []models.Blog
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND (publish_at IS NULL OR publish_at <= ?)", models.PostStatusApproved, time.Now()).
+				Order("publish_at asc").Limit(publishBatchSize).Find(&due).Error
+			if err != nil {
+				return err
+			}
+			for i := range due {
+				if err := TransitionPost(tx, &due[i], models.PostStatusPublished, 0, "", false); err != nil {
+					return err
+				}
+			}
+			published = len(due)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if published > 0 {
+			log.Printf("Published %d scheduled posts.\n", published)
+		}
+		if published < publishBatchSize {
+			return nil
+		}
+	}
+}
+
+// BackfillPublishedAt gives posts that were published before PublishedAt
+// existed their creation time as publication time.
+func BackfillPublishedAt() error {
+	return database.DB.Model(&models.Blog{}).
+		Where("status = ? AND published_at IS NULL", models.PostStatusPublished).
+		UpdateColumn("published_at", gorm.Expr("created_at")).Error
+}
+
+// MigrateLegacyPostApproval converts the old is_approved flag on blogs into a
+// status: approved posts become published, the rest wait in the review queue.
+// The old column is dropped afterwards, so this only does work once.
+func MigrateLegacyPostApproval() error {
+	migrator := database.DB.Migrator()
+	if !migrator.HasColumn(&models.Blog{}, "is_approved") {
+		return nil
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("UPDATE blogs SET status = CASE WHEN is_approved THEN ? ELSE ? END",
+			models.PostStatusPublished, models.PostStatusSubmitted).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn(&models.Blog{}, "is_approved"); err != nil {
+			return err
+		}
+		log.Println("Migrated blogs.is_approved to blogs.status.")
+		return nil
+	})
+}
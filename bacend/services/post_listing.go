@@ -0,0 +1,221 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Post listing sort orders.
+const (
+	PostSortNewest        = "newest"
+	PostSortOldest        = "oldest"
+	PostSortMostCommented = "most_commented"
+	PostSortMostLiked     = "most_liked"
+)
+
+// Counts selected alongside every listed post.
+const (
+	commentCountSQL = "(SELECT count(*) FROM comments WHERE comments.blog_id = blogs.id AND comments.is_approved AND comments.deleted_at IS NULL)"
+	likeCountSQL    = "(SELECT count(*) FROM post_likes WHERE post_likes.blog_id = blogs.id)"
+)
+
+// postSort is the key a listing is ordered by; blogs.id breaks ties so the
+// order is total and cursors are exact.
+type postSort struct {
+	expr string
+	desc bool
+	// byCount tells counts apart from timestamps in cursors.
+	byCount bool
+}
+
+var postSorts = map[string]postSort{
+	PostSortNewest:        {expr: "blogs.published_at", desc: true},
+	PostSortOldest:        {expr: "blogs.published_at", desc: false},
+	PostSortMostCommented: {expr: commentCountSQL, desc: true, byCount: true},
+	PostSortMostLiked:     {expr: likeCountSQL, desc: true, byCount: true},
+}
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was issued
+// for a different sort order.
+var ErrInvalidCursor = errors.New("invalid listing cursor")
+
+// IsValidPostSort reports whether sort is one of the PostSort constants.
+func IsValidPostSort(sort string) bool {
+	_, ok := postSorts[sort]
+	return ok
+}
+
+// PostListOptions selects and orders a listing of published posts.
+type PostListOptions struct {
+	Sort  string // one of the PostSort constants
+	Limit int
+	// Filter narrows the listing, e.g. to an author or tag; nil lists everything.
+	Filter func(db *gorm.DB) *gorm.DB
+	// Cursor continues a listing after the page that returned it. With a
+	// cursor, Offset is ignored and no total is counted.
+	Cursor string
+	Offset int
+}
+
+// PostListPage is one page of a post listing. NextCursor is empty on the last page.
+type PostListPage struct {
+	Posts      []models.Blog
+	NextCursor string
+	Total      int64 // only counted for offset pages
+}
+
+// postCursor is the position after the last post of a page, in the sort
+// order it was issued for.
+type postCursor struct {
+	Sort  string     `json:"s"`
+	Time  *time.Time `json:"t,omitempty"`
+	Count int64      `json:"c,omitempty"`
+	ID    uint       `json:"id"`
+}
+
+func encodePostCursor(cursor postCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePostCursor(encoded string, sort string) (postCursor, error) {
+	var cursor postCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.Sort != sort {
+		return cursor, ErrInvalidCursor
+	}
+	if !postSorts[sort].byCount && cursor.Time == nil {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// ListPublishedPosts returns one page of published posts, with their author,
+// category, tags and comment and like counts. Pages can be addressed by
+// offset or by the cursor returned with the previous page. With the newest and
+// oldest sorts the cursor stays fast deep into large listings; the count based
+// sorts compare per-post count subqueries that no index covers, so they get
+// slower as the listing grows either way.
+func ListPublishedPosts(options PostListOptions) (PostListPage, error) {
+	var page PostListPage
+	sort, ok := postSorts[options.Sort]
+	if !ok {
+		sort = postSorts[PostSortNewest]
+		options.Sort = PostSortNewest
+	}
+
+	published := func() *gorm.DB {
+		query := database.DB.Model(&models.Blog{}).Where("blogs.status = ?", models.PostStatusPublished)
+		if options.Filter != nil {
+			query = options.Filter(query)
+		}
+		return query
+	}
+
+	query := published().
+		Select("blogs.*, " + commentCountSQL + " AS comment_count, " + likeCountSQL + " AS like_count").
+		Preload("User").Preload("Category").Preload("Tags")
+	direction, after := "asc", ">"
+	if sort.desc {
+		direction, after = "desc", "<"
+	}
+	query = query.Order(sort.expr + " " + direction + ", blogs.id " + direction)
+
+	if options.Cursor != "" {
+		cursor, err := decodePostCursor(options.Cursor, options.Sort)
+		if err != nil {
+			return page, err
+		}
+		var value interface{} = cursor.Count
+		if !sort.byCount {
+			value = *cursor.Time
+		}
+		query = query.Where("("+sort.expr+", blogs.id) "+after+" (?, ?)", value, cursor.ID)
+	} else {
+		if err := published().Count(&page.Total).Error; err != nil {
+			return page, err
+		}
+		query = query.Offset(options.Offset)
+	}
+
+	// One extra row tells whether there is a next page.
+	if err := query.Limit(options.Limit + 1).Find(&page.Posts).Error; err != nil {
+		return page, err
+	}
+	if len(page.Posts) > options.Limit {
+		page.Posts = page.Posts[:options.Limit]
+		last := page.Posts[len(page.Posts)-1]
+		next := postCursor{Sort: options.Sort, ID: last.ID}
+		if sort.byCount {
+			next.Count = countOf(sort, last)
+		} else {
+			next.Time = last.PublishedAt
+		}
+		page.NextCursor = encodePostCursor(next)
+	}
+	return page, nil
+}
+
+// countOf returns the count a post is sorted by under a count sort.
+func countOf(sort postSort, post models.Blog) int64 {
+	count := post.LikeCount
+	if sort.expr == commentCountSQL {
+		count = post.CommentCount
+	}
+	if count == nil {
+		return 0
+	}
+	return *count
+}
+
+// PostAuthorFilter narrows a listing to the posts of one author.
+func PostAuthorFilter(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB { return db.Where("blogs.user_id = ?", userID) }
+}
+
+// PostTagFilter narrows a listing to the posts with the tag named name.
+func PostTagFilter(name string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("blogs.id IN (?)", database.DB.Table("blog_tags").Select("blog_tags.blog_id").
+			Joins("JOIN tags ON tags.id = blog_tags.tag_id").Where("tags.name = ?", name))
+	}
+}
+
+// PostCategoryFilter narrows a listing to the posts of a category and its subcategories.
+func PostCategoryFilter(categoryID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("blogs.category_id IN (?)", CategoryTree(database.DB, categoryID))
+	}
+}
+
+// PostPublishedBetween narrows a listing to posts published in [from, to).
+// A nil bound is open.
+func PostPublishedBetween(from, to *time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if from != nil {
+			db = db.Where("blogs.published_at >= ?", *from)
+		}
+		if to != nil {
+			db = db.Where("blogs.published_at < ?", *to)
+		}
+		return db
+	}
+}
+
+// CombinePostFilters applies several listing filters in turn.
+func CombinePostFilters(filters ...func(db *gorm.DB) *gorm.DB) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, filter := range filters {
+			if filter != nil {
+				db = filter(db)
+			}
+		}
+		return db
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"Gin-Blog-Website/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// revisionFields are the post columns captured by a revision. Editing any of
+// them records a new revision; other fields such as publish_at do not.
+var revisionFields = []string{"title", "description", "image"}
+
+// ErrRevisionUnchanged is returned when restoring a revision whose content is
+// already the post's current content.
+var ErrRevisionUnchanged = errors.New("revision matches the current content")
+
+// RecordRevision stores the current content of post as its next revision.
+// editorID is the user who made the change, or 0 for the server itself;
+// restoredFrom is the number of the revision that was restored, if any.
+// Posts written before revisions existed first get their content as it was
+// stored as revision 1, so the first tracked edit still has something to diff
+// against.
+func RecordRevision(tx *gorm.DB, post models.Blog, editorID uint, restoredFrom *int) (models.PostRevision, error) {
+	var last models.PostRevision
+	err := tx.Where("blog_id = ?", post.ID).Order("number desc").Limit(1).Find(&last).Error
+	if err != nil {
+		return last, err
+	}
+
+	revision := models.PostRevision{
+		BlogID:       post.ID,
+		Number:       last.Number + 1,
+		Title:        post.Title,
+		Description:  post.Description,
+		Image:        post.Image,
+		RestoredFrom: restoredFrom,
+	}
+	if editorID != 0 {
+		revision.EditorID = &editorID
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return revision, err
+	}
+	return revision, nil
+}
+
+// ensureBaselineRevision records post as it is now as revision 1 when it has
+// no revisions yet, i.e. when it was written before revisions were tracked.
+func ensureBaselineRevision(tx *gorm.DB, post models.Blog) error {
+	var count int64
+	if err := tx.Model(&models.PostRevision{}).Where("blog_id = ?", post.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := RecordRevision(tx, post, post.UserID, nil)
+	return err
+}
+
+// contentChanged reports whether updates change any of the revisioned fields of post.
+func contentChanged(post models.Blog, updates map[string]interface{}) bool {
+	current := map[string]string{"title": post.Title, "description": post.Description, "image": post.Image}
+	for _, field := range revisionFields {
+		value, ok := updates[field]
+		if !ok {
+			continue
+		}
+		if text, _ := value.(string); text != current[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyPostEdit applies an author's updates to post and records a revision
+// when its content changes. A new slug must be free; the old one keeps
+// redirecting to the post. Approved and published posts whose content
+// changes go back to the review queue, so nothing reaches readers without a
+// reviewer having seen it; a published post is hidden until it is approved
+// again. It reports whether the post was sent back for review.
+func ApplyPostEdit(tx *gorm.DB, post *models.Blog, updates map[string]interface{}, editorID uint) (bool, error) {
+	return applyPostEdit(tx, post, updates, editorID, nil)
+}
+
+// RestoreRevision makes the content of revision the current content of post,
+// as a new revision, following the same review rule as ApplyPostEdit.
+func RestoreRevision(tx *gorm.DB, post *models.Blog, revision models.PostRevision, editorID uint) (bool, error) {
+	updates := map[string]interface{}{
+		"title":       revision.Title,
+		"description": revision.Description,
+		"image":       revision.Image,
+	}
+	if !contentChanged(*post, updates) {
+		return false, ErrRevisionUnchanged
+	}
+	number := revision.Number
+	return applyPostEdit(tx, post, updates, editorID, &number)
+}
+
+func applyPostEdit(tx *gorm.DB, post *models.Blog, updates map[string]interface{}, editorID uint, restoredFrom *int) (bool, error) {
+	changed := contentChanged(*post, updates)
+	if changed {
+		if err := ensureBaselineRevision(tx, *post); err != nil {
+			return false, err
+		}
+	}
+	if slug, ok := updates["slug"].(string); ok && slug != post.Slug {
+		if err := changePostSlug(tx, *post, slug); err != nil {
+			return false, err
+		}
+	}
+
+	// Only apply the edit to the version the editor saw.
+	versioned := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	for field, value := range updates {
+		versioned[field] = value
+	}
+	result := tx.Model(post).Where("version = ?", post.Version).Updates(versioned)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrPostModified
+	}
+	if err := tx.First(post, post.ID).Error; err != nil {
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if _, err := RecordRevision(tx, *post, editorID, restoredFrom); err != nil {
+		return false, err
+	}
+	if post.Status != models.PostStatusApproved && post.Status != models.PostStatusPublished {
+		return false, nil
+	}
+	if err := TransitionPost(tx, post, models.PostStatusSubmitted, editorID, "", false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
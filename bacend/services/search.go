@@ -0,0 +1,118 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"html"
+	"strings"
+)
+
+// searchConfig is the text search configuration used to index and query posts.
+const searchConfig = "english"
+
+// Highlight markers put around matches by ts_headline. They are private-use
+// characters, so they cannot clash with post text, and are swapped for <mark>
+// tags after the rest of the snippet has been HTML-escaped.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// SearchHit is one post found by SearchPublishedPosts, with HTML snippets in
+// which the matching words are wrapped in <mark> tags.
+type SearchHit struct {
+	Post    models.Blog `json:"post"`
+	Rank    float64     `json:"rank"`
+	Title   string      `json:"title_highlight"`
+	Snippet string      `json:"snippet"`
+}
+
+// MigratePostSearch adds the search vector of posts and its GIN index. The
+// vector is a generated column, so Postgres keeps it in sync with the title
+// (weight A) and description (weight B) on every insert and update, whichever
+// code path makes it.
+func MigratePostSearch() error {
+	err := database.DB.Exec(`ALTER TABLE blogs ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('` + searchConfig + `', coalesce(description, '')), 'B')
+		) STORED`).Error
+	if err != nil {
+		return err
+	}
+	return database.DB.Exec("CREATE INDEX IF NOT EXISTS idx_blogs_search_vector ON blogs USING GIN (search_vector)").Error
+}
+
+// SearchPublishedPosts runs a web-style search query ("quoted phrases", -not,
+// or) over published posts, best matches first. It returns one page of hits
+// and the total number of matching posts.
+func SearchPublishedPosts(query string, limit int, offset int) ([]SearchHit, int64, error) {
+	matches := database.DB.Model(&models.Blog{}).
+		Where("status = ? AND search_vector @@ websearch_to_tsquery(?, ?)", models.PostStatusPublished, searchConfig, query)
+
+	var total int64
+	if err := matches.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []SearchHit{}, 0, nil
+	}
+
+	options := `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+	var rows []struct {
+		ID      uint
+		Rank    float64
+		Title   string
+		Snippet string
+	}
+	err := database.DB.Model(&models.Blog{}).
+		Select("id, ts_rank(search_vector, websearch_to_tsquery(?, ?)) AS rank, "+
+			"ts_headline(?, title, websearch_to_tsquery(?, ?), ?) AS title, "+
+			"ts_headline(?, description, websearch_to_tsquery(?, ?), ?) AS snippet",
+			searchConfig, query,
+			searchConfig, searchConfig, query, options+", HighlightAll=true",
+			searchConfig, searchConfig, query, options+", MaxWords=35, MinWords=15, MaxFragments=2").
+		Where("status = ? AND search_vector @@ websearch_to_tsquery(?, ?)", models.PostStatusPublished, searchConfig, query).
+		Order("rank desc, published_at desc, id desc").Limit(limit).Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var posts []models.Blog
+	err = database.DB.Preload("User").Preload("Category").Preload("Tags").
+		Where("id IN ? AND status = ?", ids, models.PostStatusPublished).Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.Blog, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	hits := make([]SearchHit, 0, len(rows))
+	for _, row := range rows {
+		post, ok := byID[row.ID]
+		if !ok {
+			continue // unpublished or trashed in between the two queries
+		}
+		hits = append(hits, SearchHit{
+			Post:    post,
+			Rank:    row.Rank,
+			Title:   highlightHTML(row.Title),
+			Snippet: highlightHTML(row.Snippet),
+		})
+	}
+	return hits, total, nil
+}
+
+// highlightHTML escapes a ts_headline result and turns its markers into <mark> tags.
+func highlightHTML(headline string) string {
+	escaped := html.EscapeString(headline)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
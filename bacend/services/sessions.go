@@ -0,0 +1,109 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval throttles LastSeenAt writes so authenticated requests
+// do not each cost a database write.
+const sessionTouchInterval = time.Minute
+
+// ErrSessionInvalid is returned for unknown, expired or revoked sessions.
+var ErrSessionInvalid = errors.New("session is invalid or has been revoked")
+
+// CreateSession registers a new device session for the user.
+func CreateSession(tx *gorm.DB, userID uint, jti, userAgent, ip string) (*models.Session, error) {
+	now := time.Now()
+	session := models.Session{
+		JTI:        jti,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(utils.RefreshTokenTTL),
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ValidateSession loads the session behind an access token and checks that it
+// is still active and belongs to userID. LastSeenAt and IP are refreshed at most
+// once per sessionTouchInterval.
+func ValidateSession(jti string, userID uint, ip string) (*models.Session, error) {
+	if jti == "" {
+		return nil, ErrSessionInvalid
+	}
+
+	var session models.Session
+	if err := database.DB.Where("jti = ?", jti).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionInvalid
+		}
+		return nil, err
+	}
+	if session.UserID != userID || !session.IsActive() {
+		return nil, ErrSessionInvalid
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		session.LastSeenAt = time.Now()
+		session.IP = ip
+		database.DB.Model(&session).Updates(map[string]interface{}{"last_seen_at": session.LastSeenAt, "ip": ip})
+	}
+	return &session, nil
+}
+
+// ExtendSession pushes the session expiry forward after a successful refresh.
+func ExtendSession(tx *gorm.DB, jti string) error {
+	now := time.Now()
+	return tx.Model(&models.Session{}).Where("jti = ?", jti).Updates(map[string]interface{}{
+		"last_seen_at": now,
+		"expires_at":   now.Add(utils.RefreshTokenTTL),
+	}).Error
+}
+
+// RevokeSession revokes a single session together with its refresh token family.
+func RevokeSession(tx *gorm.DB, jti string) error {
+	err := tx.Model(&models.Session{}).
+		Where("jti = ? AND revoked_at IS NULL", jti).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+	return RevokeTokenFamily(tx, jti)
+}
+
+// RevokeUserSessions revokes every session of a user except exceptJTI, which may
+// be empty to log the user out everywhere.
+func RevokeUserSessions(tx *gorm.DB, userID uint, exceptJTI string) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND jti <> ? AND revoked_at IS NULL", userID, exceptJTI).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, exceptJTI).
+			Update("revoked_at", now).Error
+	})
+}
+
+// ListActiveSessions returns the user's sessions that are still usable, most recently used first.
+func ListActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
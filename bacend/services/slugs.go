@@ -0,0 +1,109 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrSlugTaken is returned when a slug is, or was, in use by another post.
+var ErrSlugTaken = errors.New("slug is already in use")
+
+// slugBackfillBatchSize is how many posts BackfillPostSlugs handles per query.
+const slugBackfillBatchSize = 200
+
+// SlugAvailable reports whether slug may be used by the post postID: no other
+// post, trashed or not, has it now or had it before. postID is 0 for a new post.
+func SlugAvailable(tx *gorm.DB, slug string, postID uint) (bool, error) {
+	var posts, history int64
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("slug = ? AND id <> ?", slug, postID).Count(&posts).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Model(&models.PostSlug{}).Where("slug = ? AND blog_id <> ?", slug, postID).Count(&history).Error; err != nil {
+		return false, err
+	}
+	return posts == 0 && history == 0, nil
+}
+
+// UniqueSlug derives an unused slug from title, adding -2, -3, ... when the
+// plain slug is taken. postID is 0 for a new post.
+func UniqueSlug(tx *gorm.DB, title string, postID uint) (string, error) {
+	base := utils.Slugify(title)
+	if base == "" {
+		base = "post"
+	}
+
+	for n := 1; ; n++ {
+		candidate := base
+		if n > 1 {
+			suffix := "-" + strconv.Itoa(n)
+			if len(base)+len(suffix) > utils.MaxSlugLength {
+				candidate = strings.TrimRight(base[:utils.MaxSlugLength-len(suffix)], "-")
+			}
+			candidate += suffix
+		}
+		available, err := SlugAvailable(tx, candidate, postID)
+		if err != nil {
+			return "", err
+		}
+		if available {
+			return candidate, nil
+		}
+	}
+}
+
+// changePostSlug checks that post may use slug and keeps its current slug in
+// the history so links to it redirect. Going back to one of the post's own
+// old slugs takes it out of the history.
+func changePostSlug(tx *gorm.DB, post models.Blog, slug string) error {
+	available, err := SlugAvailable(tx, slug, post.ID)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return ErrSlugTaken
+	}
+
+	if err := tx.Where("blog_id = ? AND slug = ?", post.ID, slug).Delete(&models.PostSlug{}).Error; err != nil {
+		return err
+	}
+	if post.Slug == "" {
+		return nil
+	}
+	return tx.Create(&models.PostSlug{BlogID: post.ID, Slug: post.Slug}).Error
+}
+
+// BackfillPostSlugs gives every post without a slug one derived from its title.
+func BackfillPostSlugs() error {
+	var filled int
+	for {
+		var posts []models.Blog
+		err := database.DB.Unscoped().Where("slug = ''").Order("id asc").Limit(slugBackfillBatchSize).Find(&posts).Error
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			slug, err := UniqueSlug(database.DB, post.Title, post.ID)
+			if err != nil {
+				return err
+			}
+			if err := database.DB.Unscoped().Model(&post).UpdateColumn("slug", slug).Error; err != nil {
+				return err
+			}
+		}
+		filled += len(posts)
+		if len(posts) < slugBackfillBatchSize {
+			break
+		}
+	}
+	if filled > 0 {
+		log.Printf("Generated slugs for %d posts.\n", filled)
+	}
+	return nil
+}
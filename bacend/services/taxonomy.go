@@ -0,0 +1,85 @@
+package services
+
+import (
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NormalizeTags turns tag names into their stored slug form, dropping empty
+// and duplicate names while keeping the order they were given in.
+func NormalizeTags(names []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, name := range names {
+		name = utils.Slugify(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+// SetPostTags replaces the tags of post, creating tags that do not exist yet.
+func SetPostTags(tx *gorm.DB, post *models.Blog, names []string) error {
+	names = NormalizeTags(names)
+	tags := []models.Tag{}
+	if len(names) > 0 {
+		for _, name := range names {
+			tags = append(tags, models.Tag{Name: name})
+		}
+		// Another post may be creating the same tag right now.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+		tags = nil
+		if err := tx.Where("name IN ?", names).Order("name asc").Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(post).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+	post.Tags = tags
+	return nil
+}
+
+// deletePostTags removes the tags of the posts selected by postIDs, before
+// the posts themselves are deleted for good.
+func deletePostTags(tx *gorm.DB, postIDs *gorm.DB) error {
+	return tx.Exec("DELETE FROM blog_tags WHERE blog_id IN (?)", postIDs).Error
+}
+
+// CategoryTree selects the IDs of a category and all of its subcategories,
+// for use as a subquery.
+func CategoryTree(tx *gorm.DB, categoryID uint) *gorm.DB {
+	return tx.Raw(`WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+	) SELECT id FROM tree`, categoryID)
+}
+
+// IsInCategoryTree reports whether candidateID is rootID or one of its subcategories.
+func IsInCategoryTree(tx *gorm.DB, rootID uint, candidateID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Category{}).Where("id = ? AND id IN (?)", candidateID, CategoryTree(tx, rootID)).Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteCategory deletes a category. Its subcategories and posts move up to
+// its parent, or become top-level and uncategorized when it has none.
+func DeleteCategory(tx *gorm.DB, category models.Category) error {
+	if err := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).Update("parent_id", category.ParentID).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("category_id = ?", category.ID).UpdateColumn("category_id", category.ParentID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&category).Error
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"Gin-Blog-Website/utils"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown or expired refresh tokens.
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	// ErrRefreshTokenReused is returned when an already rotated token is presented again.
+	// The whole token family has been revoked by the time it is returned.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// IssueRefreshToken creates a new refresh token in the given family and
+// returns the raw token. Only its hash is stored.
+func IssueRefreshToken(tx *gorm.DB, userID uint, familyID string) (string, *models.RefreshToken, error) {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+	return raw, &token, nil
+}
+
+// RotateRefreshToken exchanges a raw refresh token for a new one in the same family.
+// Presenting a token that has already been rotated is treated as theft: the
+// session and its whole token family are revoked and ErrRefreshTokenReused is returned.
+func RotateRefreshToken(raw string) (string, *models.RefreshToken, error) {
+	var newRaw string
+	var issued *models.RefreshToken
+	reused := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		// Lock the row so two concurrent refreshes cannot both rotate the same token.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", utils.HashToken(raw)).
+			First(&current).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil && current.ReplacedBy != nil {
+			log.Printf("Refresh token %d of family %s reused, revoking session.\n", current.ID, current.FamilyID)
+			reused = true
+			return RevokeSession(tx, current.FamilyID)
+		}
+		if !current.IsActive() {
+			return ErrRefreshTokenInvalid
+		}
+
+		newRaw, issued, err = IssueRefreshToken(tx, current.UserID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = tx.Model(&current).Updates(map[string]interface{}{
+			"revoked_at":  now,
+			"replaced_by": issued.ID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return ExtendSession(tx, current.FamilyID)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if reused {
+		return "", nil, ErrRefreshTokenReused
+	}
+	return newRaw, issued, nil
+}
+
+// RevokeRefreshToken revokes the session the given raw token belongs to.
+// Unknown tokens are ignored so logout is always safe to call.
+func RevokeRefreshToken(raw string) error {
+	var token models.RefreshToken
+	err := database.DB.Where("token_hash = ?", utils.HashToken(raw)).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return RevokeSession(database.DB, token.FamilyID)
+}
+
+// RevokeTokenFamily revokes every still-active refresh token in a family.
+func RevokeTokenFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
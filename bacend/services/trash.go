@@ -0,0 +1,215 @@
+package services
+
+import (
+	"Gin-Blog-Website/database"
+	"Gin-Blog-Website/models"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultTrashRetentionDays is used when TRASH_RETENTION_DAYS is unset.
+const defaultTrashRetentionDays = 30
+
+var (
+	// ErrNotInTrash is returned when restoring something that is not in the trash.
+	ErrNotInTrash = errors.New("item is not in the trash")
+	// ErrParentInTrash is returned when restoring an item whose post or author
+	// is still in the trash; the parent has to be restored first.
+	ErrParentInTrash = errors.New("the item's post or author is still in the trash")
+)
+
+// TrashRetentionPeriod returns how long trashed items are kept before they are
+// purged for good, from TRASH_RETENTION_DAYS (default 30).
+func TrashRetentionPeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// trashTimestamp is the deletion time stamped on everything trashed together.
+// Restoring an item brings back exactly the children carrying the same stamp,
+// and not the ones that were trashed on their own before. Postgres keeps
+// microseconds, so the value is truncated to compare equal once stored.
+func trashTimestamp() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// TrashPost moves a post and its comments to the trash.
+func TrashPost(tx *gorm.DB, post models.Blog) error {
+	now := trashTimestamp()
+	if err := tx.Model(&models.Comment{}).Where("blog_id = ?", post.ID).Update("deleted_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&post).Update("deleted_at", now).Error
+}
+
+// RestorePost takes a post out of the trash together with the comments that
+// were trashed with it.
+func RestorePost(tx *gorm.DB, postID uint) (models.Blog, error) {
+	var post models.Blog
+	if err := tx.Unscoped().First(&post, postID).Error; err != nil {
+		return post, err
+	}
+	if !post.DeletedAt.Valid {
+		return post, ErrNotInTrash
+	}
+
+	var author int64
+	if err := tx.Model(&models.User{}).Where("id = ?", post.UserID).Count(&author).Error; err != nil {
+		return post, err
+	}
+	if author == 0 {
+		return post, ErrParentInTrash
+	}
+
+	err := tx.Unscoped().Model(&models.Comment{}).
+		Where("blog_id = ? AND deleted_at = ?", post.ID, post.DeletedAt.Time).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		return post, err
+	}
+	if err := tx.Unscoped().Model(&post).Update("deleted_at", nil).Error; err != nil {
+		return post, err
+	}
+	return post, nil
+}
+
+// RestoreComment takes a comment out of the trash. Its post and author must not be trashed.
+func RestoreComment(tx *gorm.DB, commentID uint) (models.Comment, error) {
+	var comment models.Comment
+	if err := tx.Unscoped().First(&comment, commentID).Error; err != nil {
+		return comment, err
+	}
+	if !comment.DeletedAt.Valid {
+		return comment, ErrNotInTrash
+	}
+
+	var post, author int64
+	if err := tx.Model(&models.Blog{}).Where("id = ?", comment.BlogID).Count(&post).Error; err != nil {
+		return comment, err
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", comment.UserID).Count(&author).Error; err != nil {
+		return comment, err
+	}
+	if post == 0 || author == 0 {
+		return comment, ErrParentInTrash
+	}
+
+	if err := tx.Unscoped().Model(&comment).Update("deleted_at", nil).Error; err != nil {
+		return comment, err
+	}
+	return comment, nil
+}
+
+// trashUser moves a user to the trash. With withContent their posts, their
+// comments and other users' comments on those posts are trashed alongside.
+func trashUser(tx *gorm.DB, user models.User, withContent bool) error {
+	now := trashTimestamp()
+	if withContent {
+		postIDs := tx.Model(&models.Blog{}).Select("id").Where("user_id = ?", user.Id)
+		if err := tx.Model(&models.Comment{}).Where("user_id = ? OR blog_id IN (?)", user.Id, postIDs).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Blog{}).Where("user_id = ?", user.Id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(&user).Update("deleted_at", now).Error
+}
+
+// RestoreUser takes a user out of the trash together with the posts and
+// comments that were trashed with them. They have to log in again, since
+// their sessions were ended when they were deleted.
+func RestoreUser(tx *gorm.DB, userID uint) (models.User, error) {
+	var user models.User
+	if err := tx.Unscoped().First(&user, userID).Error; err != nil {
+		return user, err
+	}
+	if !user.DeletedAt.Valid {
+		return user, ErrNotInTrash
+	}
+
+	stamp := user.DeletedAt.Time
+	postIDs := tx.Unscoped().Model(&models.Blog{}).Select("id").Where("user_id = ? AND deleted_at = ?", user.Id, stamp)
+	err := tx.Unscoped().Model(&models.Comment{}).
+		Where("deleted_at = ? AND (user_id = ? OR blog_id IN (?))", stamp, user.Id, postIDs).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		return user, err
+	}
+	if err := tx.Unscoped().Model(&models.Blog{}).Where("user_id = ? AND deleted_at = ?", user.Id, stamp).Update("deleted_at", nil).Error; err != nil {
+		return user, err
+	}
+	if err := tx.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// PurgeTrash permanently deletes comments, posts and users that have been in
+// the trash for longer than TrashRetentionPeriod, in that order so nothing is
+// left pointing at a purged row.
+func PurgeTrash() error {
+	cutoff := time.Now().Add(-TrashRetentionPeriod())
+
+	comments := database.DB.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Comment{})
+	if comments.Error != nil {
+		return comments.Error
+	}
+
+	var posts int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		postIDs := tx.Unscoped().Model(&models.Blog{}).Select("id").Where("deleted_at < ?", cutoff)
+		if err := tx.Unscoped().Where("blog_id IN (?)", postIDs).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostStatusChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostSlug{}).Error; err != nil {
+			return err
+		}
+		if err := deletePostTags(tx, postIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id IN (?)", postIDs).Delete(&models.PostLike{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Blog{})
+		posts = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return err
+	}
+
+	var users []models.User
+	if err := database.DB.Unscoped().Where("deleted_at < ?", cutoff).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		// Content still owned by the user was restored or left behind after
+		// they were trashed; keep it but detach it from the purged account.
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			return EraseAccount(tx, user, models.ContentPolicyAnonymize)
+		})
+		if err != nil {
+			log.Printf("Error purging trashed user %d: %v\n", user.Id, err)
+		}
+	}
+
+	if comments.RowsAffected > 0 || posts > 0 || len(users) > 0 {
+		log.Printf("Purged %d comments, %d posts and %d users from the trash.\n", comments.RowsAffected, posts, len(users))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import "strings"
+
+// Line diff operations.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the LCS table. Larger inputs are reported as a full
+// replacement rather than spending unbounded memory on a precise diff.
+const maxDiffCells = 4_000_000
+
+// DiffLine is one line of a line-level diff. OldLine and NewLine are 1-based
+// line numbers in the old and new text; the side a line is missing from is 0.
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// DiffLines returns a line-level diff turning oldText into newText, based on
+// the longest common subsequence of their lines.
+func DiffLines(oldText, newText string) []DiffLine {
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// Trim the common prefix and suffix; edits are usually local.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	diff = append(diff, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)...)
+	for i := 0; i < suffix; i++ {
+		oldIndex, newIndex := len(a)-suffix+i, len(b)-suffix+i
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: a[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+	return diff
+}
+
+// diffMiddle diffs the lines left after trimming; offset is the number of
+// trimmed leading lines, to keep line numbers absolute.
+func diffMiddle(a, b []string, offset int) []DiffLine {
+	var diff []DiffLine
+	if len(a)*len(b) > maxDiffCells {
+		for i, line := range a {
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: line, OldLine: offset + i + 1})
+		}
+		for j, line := range b {
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: line, NewLine: offset + j + 1})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: a[i], OldLine: offset + i + 1, NewLine: offset + j + 1})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j], NewLine: offset + j + 1})
+			j++
+		default:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i], OldLine: offset + i + 1})
+			i++
+		}
+	}
+	return diff
+}
+
+// splitLines splits text into lines, treating "\r\n" like "\n". Empty text has no lines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func eq(text string, oldLine, newLine int) DiffLine {
+	return DiffLine{Op: DiffEqual, Text: text, OldLine: oldLine, NewLine: newLine}
+}
+
+func ins(text string, newLine int) DiffLine {
+	return DiffLine{Op: DiffInsert, Text: text, NewLine: newLine}
+}
+
+func del(text string, oldLine int) DiffLine {
+	return DiffLine{Op: DiffDelete, Text: text, OldLine: oldLine}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []DiffLine
+	}{
+		{"both empty", "", "", []DiffLine{}},
+		{"from empty", "", "a\nb", []DiffLine{ins("a", 1), ins("b", 2)}},
+		{"to empty", "a\nb", "", []DiffLine{del("a", 1), del("b", 2)}},
+		{"identical", "a\nb\nc", "a\nb\nc", []DiffLine{eq("a", 1, 1), eq("b", 2, 2), eq("c", 3, 3)}},
+		{"crlf is the same as lf", "a\r\nb", "a\nb", []DiffLine{eq("a", 1, 1), eq("b", 2, 2)}},
+		{"append line", "a\nb", "a\nb\nc", []DiffLine{eq("a", 1, 1), eq("b", 2, 2), ins("c", 3)}},
+		{"prepend line", "b\nc", "a\nb\nc", []DiffLine{ins("a", 1), eq("b", 1, 2), eq("c", 2, 3)}},
+		{"delete last line", "a\nb\nc", "a\nb", []DiffLine{eq("a", 1, 1), eq("b", 2, 2), del("c", 3)}},
+		{"delete first line", "a\nb\nc", "b\nc", []DiffLine{del("a", 1), eq("b", 2, 1), eq("c", 3, 2)}},
+		{
+			"change in the middle keeps prefix and suffix",
+			"a\nb\nc\nd",
+			"a\nx\nc\nd",
+			[]DiffLine{eq("a", 1, 1), del("b", 2), ins("x", 2), eq("c", 3, 3), eq("d", 4, 4)},
+		},
+		{
+			"repeated lines at both ends",
+			"a\na\na",
+			"a\na",
+			[]DiffLine{eq("a", 1, 1), eq("a", 2, 2), del("a", 3)},
+		},
+		{
+			"lcs inside the changed block",
+			"start\na\nb\nc\nend",
+			"start\nb\nx\nc\nend",
+			[]DiffLine{eq("start", 1, 1), del("a", 2), eq("b", 3, 2), ins("x", 3), eq("c", 4, 4), eq("end", 5, 5)},
+		},
+		{
+			"completely different",
+			"a\nb",
+			"c\nd",
+			[]DiffLine{del("a", 1), del("b", 2), ins("c", 1), ins("d", 2)},
+		},
+		{"trailing newline adds an empty line", "a", "a\n", []DiffLine{eq("a", 1, 1), ins("", 2)}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DiffLines(tc.old, tc.new)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("DiffLines(%q, %q) =\n%v\nwant\n%v", tc.old, tc.new, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestDiffLinesReconstructs checks that every diff rebuilds both texts, with
+// consecutive line numbers on each side.
+func TestDiffLinesReconstructs(t *testing.T) {
+	pairs := [][2]string{
+		{"a\nb\nc\nd\ne", "a\nc\nd\nf\ne"},
+		{"x\ny\nx\ny", "y\nx\ny\nx"},
+		{"one\ntwo\nthree", "zero\none\nthree\nfour"},
+		{"same\nsame\nsame", "same"},
+	}
+	for _, pair := range pairs {
+		var oldLines, newLines []string
+		for _, line := range DiffLines(pair[0], pair[1]) {
+			if line.Op != DiffInsert {
+				oldLines = append(oldLines, line.Text)
+				if line.OldLine != len(oldLines) {
+					t.Errorf("%q: old line %q numbered %d, want %d", pair, line.Text, line.OldLine, len(oldLines))
+				}
+			}
+			if line.Op != DiffDelete {
+				newLines = append(newLines, line.Text)
+				if line.NewLine != len(newLines) {
+					t.Errorf("%q: new line %q numbered %d, want %d", pair, line.Text, line.NewLine, len(newLines))
+				}
+			}
+		}
+		if got := strings.Join(oldLines, "\n"); got != pair[0] {
+			t.Errorf("old side rebuilt as %q, want %q", got, pair[0])
+		}
+		if got := strings.Join(newLines, "\n"); got != pair[1] {
+			t.Errorf("new side rebuilt as %q, want %q", got, pair[1])
+		}
+	}
+}
+
+// TestDiffLinesTooLarge checks the fallback to a full replacement once the
+// LCS table would exceed maxDiffCells. Only the shared first and last lines
+// are trimmed and kept as equal.
+func TestDiffLinesTooLarge(t *testing.T) {
+	const n = 2001 // n*n > maxDiffCells
+	oldLines := []string{"head"}
+	newLines := []string{"head"}
+	for i := 0; i < n; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("old %d", i))
+		newLines = append(newLines, fmt.Sprintf("new %d", i))
+	}
+	oldLines = append(oldLines, "tail")
+	newLines = append(newLines, "tail")
+
+	diff := DiffLines(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
+	if len(diff) != 2*n+2 {
+		t.Fatalf("got %d diff lines, want %d", len(diff), 2*n+2)
+	}
+	if diff[0] != eq("head", 1, 1) || diff[len(diff)-1] != eq("tail", n+2, n+2) {
+		t.Errorf("prefix or suffix not kept: first %v, last %v", diff[0], diff[len(diff)-1])
+	}
+	for i, line := range diff[1 : n+1] {
+		if line != del(oldLines[i+1], i+2) {
+			t.Fatalf("line %d = %v, want deletion of %q", i+1, line, oldLines[i+1])
+		}
+	}
+	for j, line := range diff[n+1 : 2*n+1] {
+		if line != ins(newLines[j+1], j+2) {
+			t.Fatalf("line %d = %v, want insertion of %q", n+j+1, line, newLines[j+1])
+		}
+	}
+}
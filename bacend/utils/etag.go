@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionETag formats a row version as a strong entity tag, e.g. "3".
+func VersionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// IfMatches reports whether an If-Match header value matches etag. The header
+// may list several tags or be "*"; weak tags never match, as RFC 9110 requires
+// strong comparison for If-Match.
+func IfMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -1,34 +1,126 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// Lifetimes of the two halves of a login session. Access tokens are short
+// lived and stateless; refresh tokens are stored server-side and rotated on
+// every use.
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
 
-const SecretKey = "secret"
+// Token purposes. Every JWT carries one, so a token minted for one purpose
+// (e.g. an emailed verification link) can never be replayed as a login.
+const (
+	PurposeAccess      = "access"
+	PurposeEmailVerify = "email_verify"
+	PurposeMFAPending  = "mfa_pending"
+	PurposeEmailChange = "email_change"
+)
 
+// Claims are the JWT claims used by every token the API issues.
+// Issuer holds the user ID and Id (jti) the session the token belongs to.
+// Email binds purpose tokens to the address they were sent to.
+type Claims struct {
+	jwt.StandardClaims
+	Purpose string `json:"pur,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
 
-func GenerateJwt(issuer string) (string,error) {
-claims := jwt.NewWithClaims(jwt.SigningMethodHS256,jwt.StandardClaims{
-	Issuer:issuer,
-	ExpiresAt:time.Now().Add(time.Hour*24).Unix(),
-})
-return claims.SignedString([]byte(SecretKey))
+// GenerateAccessToken issues a short-lived access token for the given user and session.
+func GenerateAccessToken(userID string, sessionID string) (string, error) {
+	now := time.Now()
+	return signToken(Claims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    userID,
+			Id:        sessionID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+		},
+		Purpose: PurposeAccess,
+	})
 }
 
+// ParseAccessToken validates an access token and returns its claims.
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	return parseClaims(tokenString, PurposeAccess)
+}
 
-func ParseJwt(cookie string) (string,error){
-	token,err := jwt.ParseWithClaims(cookie,&jwt.StandardClaims{},func(t *jwt.Token)(interface{},error){
-		return []byte(SecretKey),nil
-	}) 
-	if err != nil||!token.Valid {
-		return "",err
+// GeneratePurposeToken issues a signed token for a single purpose, such as an
+// email verification link, bound to the given user and email address.
+func GeneratePurposeToken(userID string, purpose string, email string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return signToken(Claims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    userID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+		Purpose: purpose,
+		Email:   email,
+	})
+}
+
+// GenerateEmailChangeToken issues a token confirming a change of address from
+// currentEmail to newEmail. Subject records the current address so the link
+// stops working once the email has changed by any other means.
+func GenerateEmailChangeToken(userID string, currentEmail string, newEmail string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return signToken(Claims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    userID,
+			Subject:   currentEmail,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+		Purpose: PurposeEmailChange,
+		Email:   newEmail,
+	})
+}
+
+// ParsePurposeToken validates a token issued by GeneratePurposeToken for purpose.
+func ParsePurposeToken(tokenString string, purpose string) (*Claims, error) {
+	return parseClaims(tokenString, purpose)
+}
+
+func parseClaims(tokenString string, purpose string) (*Claims, error) {
+	token, err := parseToken(tokenString, &Claims{})
+	if err != nil || !token.Valid {
+		return nil, err
 	}
-	claims,ok:= token.Claims.(*jwt.StandardClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return "",jwt.ErrInvalidKey
+		return nil, jwt.ErrInvalidKey
+	}
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("token purpose %q does not match %q", claims.Purpose, purpose)
+	}
+	return claims, nil
+}
+
+// GenerateOpaqueToken returns a random, URL-safe token suitable for refresh
+// tokens and other secrets that are only ever stored as a hash.
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
 	}
-	return claims.Issuer,nil
-}
\ No newline at end of file
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token. Only this
+// hash is persisted, so a database leak does not leak usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// minHMACSecretLength is the shortest HS256 secret we accept (256 bits).
+const minHMACSecretLength = 32
+
+// signingKey is one configured JWT key. Private is nil for keys that are kept
+// only to verify tokens signed before a rotation.
+type signingKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey
+	Public  interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+var (
+	activeKey        *signingKey
+	verificationKeys = map[string]*signingKey{}
+)
+
+// InitSigningKeys loads the JWT keys from the environment.
+//
+// JWT_KEYS is a comma separated list of kid:alg:value entries. For HS256 the
+// value is the shared secret; for RS256 and EdDSA it is the path to a PEM file
+// holding either a private key or, for retired keys, just the public key.
+// JWT_ACTIVE_KID names the key new tokens are signed with; every other key is
+// only used for verification, which lets keys be rotated without logging
+// anyone out. Example:
+//
+//	JWT_KEYS=2024-01:HS256:<32+ byte secret>,2024-06:EdDSA:/etc/blog/ed25519.pem
+//	JWT_ACTIVE_KID=2024-06
+func InitSigningKeys() error {
+	rawKeys := os.Getenv("JWT_KEYS")
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+	if rawKeys == "" || activeKID == "" {
+		return errors.New("JWT_KEYS and JWT_ACTIVE_KID must be set")
+	}
+
+	keys := map[string]*signingKey{}
+	for _, entry := range strings.Split(rawKeys, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[0] == "" {
+			return fmt.Errorf("invalid JWT_KEYS entry %q, expected kid:alg:value", entry)
+		}
+		if _, exists := keys[parts[0]]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", parts[0])
+		}
+		key, err := loadSigningKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return fmt.Errorf("JWT key %q: %w", parts[0], err)
+		}
+		keys[key.ID] = key
+	}
+
+	active, ok := keys[activeKID]
+	if !ok {
+		return fmt.Errorf("JWT_ACTIVE_KID %q is not listed in JWT_KEYS", activeKID)
+	}
+	if active.Private == nil {
+		return fmt.Errorf("active JWT key %q has no private key to sign with", activeKID)
+	}
+
+	activeKey = active
+	verificationKeys = keys
+	return nil
+}
+
+func loadSigningKey(kid, alg, value string) (*signingKey, error) {
+	switch alg {
+	case "HS256":
+		if len(value) < minHMACSecretLength {
+			return nil, fmt.Errorf("HS256 secret must be at least %d bytes", minHMACSecretLength)
+		}
+		secret := []byte(value)
+		return &signingKey{ID: kid, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}, nil
+	case "RS256", "EdDSA":
+		pemBytes, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		private, public, err := parsePEMKey(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key := &signingKey{ID: kid, Private: private, Public: public}
+		switch public.(type) {
+		case *rsa.PublicKey:
+			key.Method = jwt.SigningMethodRS256
+		case ed25519.PublicKey:
+			key.Method = SigningMethodEdDSA
+		}
+		if key.Method == nil || key.Method.Alg() != alg {
+			return nil, fmt.Errorf("PEM key type does not match algorithm %s", alg)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+// parsePEMKey decodes a PEM encoded RSA or Ed25519 key. Private is nil when the
+// PEM only holds a public key.
+func parsePEMKey(pemBytes []byte) (private interface{}, public interface{}, err error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &key.PublicKey, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, &k.PublicKey, nil
+		case ed25519.PrivateKey:
+			return k, k.Public().(ed25519.PublicKey), nil
+		}
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		return nil, key, err
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			return nil, key, nil
+		}
+		return nil, nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// signToken signs claims with the active key and stamps its kid in the header.
+func signToken(claims jwt.Claims) (string, error) {
+	if activeKey == nil {
+		return "", errors.New("JWT signing keys are not initialized")
+	}
+	token := jwt.NewWithClaims(activeKey.Method, claims)
+	token.Header["kid"] = activeKey.ID
+	return token.SignedString(activeKey.Private)
+}
+
+// parseToken verifies a token against the key named by its kid header.
+// Tokens without a kid, or whose alg does not match the key's, are rejected.
+func parseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := verificationKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.Public, nil
+	})
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set.
+// HS256 keys are symmetric and are never published.
+func JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	for _, key := range verificationKeys {
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": key.Method.Alg(),
+				"kid": key.ID,
+				"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": key.Method.Alg(),
+				"kid": key.ID,
+				"x":   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxSlugLength is the longest slug Slugify produces and ValidSlug accepts.
+const MaxSlugLength = 80
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// latinFolds spells common accented Latin letters in plain ASCII, so
+// "Crème brûlée" becomes "creme-brulee" rather than "cr-me-br-l-e".
+var latinFolds = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "ae", "å", "a", "æ", "ae",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "oe", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "ue", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// Slugify turns text into a URL slug of lowercase ASCII letters and digits
+// separated by single hyphens, at most MaxSlugLength long. It returns "" when
+// text has nothing to keep.
+func Slugify(text string) string {
+	text = latinFolds.Replace(strings.ToLower(text))
+
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range text {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+
+	result := slug.String()
+	if len(result) > MaxSlugLength {
+		result = strings.TrimRight(result[:MaxSlugLength], "-")
+	}
+	return result
+}
+
+// ValidSlug reports whether slug is in the form Slugify produces.
+func ValidSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugPattern.MatchString(slug)
+}
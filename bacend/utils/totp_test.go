@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC 6238 appendix B vectors for SHA-1. The RFC uses 8 digits; a 6 digit
+// code is the same value modulo 10^6, i.e. its last six digits.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("decoding secret: %v", err)
+	}
+	for _, tc := range rfc6238Vectors {
+		if got := totpCode(key, tc.unix/totpPeriod); got != tc.code {
+			t.Errorf("totpCode at %d = %s, want %s", tc.unix, got, tc.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", rfc6238Secret, "050471", now, true, step},
+		{"lowercase secret", strings.ToLower(rfc6238Secret), "050471", now, true, step},
+		{"spaces in code", rfc6238Secret, " 050 471 ", now, true, step},
+		{"one step late", rfc6238Secret, "050471", now.Add(totpPeriod * time.Second), true, step},
+		{"one step early", rfc6238Secret, "050471", now.Add(-totpPeriod * time.Second), true, step},
+		{"two steps late", rfc6238Secret, "050471", now.Add(2 * totpPeriod * time.Second), false, 0},
+		{"two steps early", rfc6238Secret, "050471", now.Add(-2 * totpPeriod * time.Second), false, 0},
+		{"wrong code", rfc6238Secret, "050472", now, false, 0},
+		{"eight digit code", rfc6238Secret, "14050471", now, false, 0},
+		{"short code", rfc6238Secret, "05047", now, false, 0},
+		{"empty code", rfc6238Secret, "", now, false, 0},
+		{"invalid secret", "not base32!", "050471", now, false, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(tc.secret, tc.code, tc.now)
+			if ok != tc.wantOK || gotStep != tc.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", gotStep, ok, tc.wantStep, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not unpadded base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+
+	// A freshly generated secret must validate its own current code.
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("ValidateTOTP rejected the current code of a generated secret")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("Gin Blog", "jo@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("TOTPURI is not a valid URL: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("TOTPURI = %s, want otpauth://totp/...", uri)
+	}
+	if uri.Path != "/Gin Blog:jo@example.com" {
+		t.Errorf("label = %q, want %q", uri.Path, "/Gin Blog:jo@example.com")
+	}
+	want := map[string]string{"secret": rfc6238Secret, "issuer": "Gin Blog", "algorithm": "SHA1", "digits": "6", "period": "30"}
+	for key, value := range want {
+		if got := uri.Query().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not look like xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"abcde-fghij", "abcdefghij"},
+		{"ABCDE-FGHIJ", "abcdefghij"},
+		{"abcdefghij", "abcdefghij"},
+		{"  abcde-fghij\n", "abcdefghij"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := NormalizeRecoveryCode(tc.code); got != tc.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tc.code, got, tc.want)
+		}
+	}
+}